@@ -0,0 +1,405 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ==================== Backfills ====================
+
+const (
+	maxBackfillRuns        = 1000
+	maxBackfillConcurrency = 10
+)
+
+// backfillRegistry tracks the cancel functions of backfills running in this
+// process.
+type backfillRegistry struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func newBackfillRegistry() *backfillRegistry {
+	return &backfillRegistry{cancels: map[string]context.CancelFunc{}}
+}
+
+func (r *backfillRegistry) add(id string, cancel context.CancelFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cancels[id] = cancel
+}
+
+func (r *backfillRegistry) done(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.cancels, id)
+}
+
+func (r *backfillRegistry) cancel(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cancel, ok := r.cancels[id]; ok {
+		cancel()
+	}
+}
+
+// scheduleMetadata is the "schedule" object passed to scheduled runs, so
+// nodes can use {{schedule.fire_time}} and {{schedule.logical_date}}.
+// Callers add started_at, previous_fire_time and trigger_id where known.
+func scheduleMetadata(expr string, fireTime time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"fire_time":    fireTime.Format(time.RFC3339),
+		"logical_date": fireTime.Format("2006-01-02"),
+		"expression":   expr,
+	}
+}
+
+// withScheduleInput merges the schedule metadata into a static input object.
+// The "schedule" key is reserved and always overwritten.
+func withScheduleInput(static json.RawMessage, schedule map[string]interface{}) json.RawMessage {
+	input := map[string]interface{}{}
+	json.Unmarshal(static, &input)
+	input["schedule"] = schedule
+	out, _ := json.Marshal(input)
+	return out
+}
+
+// createBackfill enumerates the schedule's fire times in [start, end] and
+// queues one pending run per tick, executed at most max_concurrency at a time.
+func (h *Handler) createBackfill(c *gin.Context) {
+	workflowID := c.Param("id")
+	var req struct {
+		TriggerID      string    `json:"trigger_id"`
+		CronSchedule   string    `json:"cron_schedule"`
+		Start          time.Time `json:"start"`
+		End            time.Time `json:"end"`
+		MaxConcurrency int       `json:"max_concurrency"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Start.IsZero() || req.End.IsZero() || !req.End.After(req.Start) {
+		c.JSON(400, gin.H{"error": "start and end are required (RFC3339) and end must be after start"})
+		return
+	}
+	if req.End.After(time.Now()) {
+		c.JSON(400, gin.H{"error": "end must not be in the future"})
+		return
+	}
+	if req.MaxConcurrency <= 0 {
+		req.MaxConcurrency = 1
+	}
+	if req.MaxConcurrency > maxBackfillConcurrency {
+		req.MaxConcurrency = maxBackfillConcurrency
+	}
+
+	var w Workflow
+	err := h.db.QueryRow("SELECT id, name, nodes, edges FROM workflows WHERE id = ?", workflowID).
+		Scan(&w.ID, &w.Name, &w.Nodes, &w.Edges)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "Workflow not found"})
+		return
+	}
+
+	trigger, msg := h.resolveBackfillTrigger(workflowID, req.TriggerID)
+	if msg != "" {
+		c.JSON(400, gin.H{"error": msg})
+		return
+	}
+	expr := req.CronSchedule
+	if expr == "" && trigger.CronSchedule != nil {
+		expr = *trigger.CronSchedule
+	}
+	sched, err := parseCronSchedule(expr)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid cron schedule: " + err.Error()})
+		return
+	}
+
+	fireTimes := cronFireTimes(sched, req.Start.Add(-time.Second), req.End, maxBackfillRuns+1)
+	if len(fireTimes) == 0 {
+		c.JSON(400, gin.H{"error": "The schedule has no fire times in that range"})
+		return
+	}
+	if len(fireTimes) > maxBackfillRuns {
+		c.JSON(400, gin.H{"error": "Backfill would create more than 1000 runs; narrow the range"})
+		return
+	}
+
+	var triggerID *string
+	if trigger.ID != "" {
+		triggerID = &trigger.ID
+	}
+	backfillID := uuid.New().String()
+	_, err = h.db.Exec(
+		"INSERT INTO backfills (id, workflow_id, trigger_id, cron_schedule, start_time, end_time, max_concurrency, total_runs) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		backfillID, workflowID, triggerID, expr, req.Start, req.End, req.MaxConcurrency, len(fireTimes),
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	queue := make([]backfillRun, 0, len(fireTimes))
+	for _, ft := range fireTimes {
+		schedule := scheduleMetadata(expr, ft)
+		schedule["backfill_id"] = backfillID
+		if triggerID != nil {
+			schedule["trigger_id"] = *triggerID
+			schedule["previous_fire_time"] = h.previousSuccessfulFireTime(*triggerID, ft)
+		}
+		input := withScheduleInput(trigger.Input, schedule)
+
+		runID := uuid.New().String()
+		_, err := h.db.Exec(
+			"INSERT INTO workflow_runs (id, workflow_id, trigger_id, backfill_id, scheduled_for, status, input) VALUES (?, ?, ?, ?, ?, 'pending', ?)",
+			runID, workflowID, triggerID, backfillID, ft, input,
+		)
+		if err != nil {
+			log.Printf("Failed to queue backfill run for %s: %v", ft, err)
+			continue
+		}
+		queue = append(queue, backfillRun{id: runID, input: input})
+	}
+	h.runBackfill(backfillID, w, triggerNodeID(trigger), queue, req.MaxConcurrency)
+
+	log.Printf("📼 Backfill %s queued %d runs for workflow '%s' (%s → %s)", backfillID, len(queue), w.Name, req.Start.Format(time.RFC3339), req.End.Format(time.RFC3339))
+	c.JSON(201, gin.H{
+		"id":         backfillID,
+		"total_runs": len(queue),
+		"first_fire": fireTimes[0],
+		"last_fire":  fireTimes[len(fireTimes)-1],
+		"status":     "running",
+		"message":    "Backfill started",
+	})
+}
+
+// backfillRun is a pending run queued by a backfill.
+type backfillRun struct {
+	id    string
+	input json.RawMessage
+}
+
+// runBackfill executes the queued runs in the background, at most
+// maxConcurrency at a time, and completes the backfill once they are done.
+func (h *Handler) runBackfill(backfillID string, w Workflow, startNodeID string, queue []backfillRun, maxConcurrency int) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h.backfills.add(backfillID, cancel)
+
+	go func() {
+		defer h.backfills.done(backfillID)
+		defer cancel()
+
+		sem := make(chan struct{}, maxConcurrency)
+		var wg sync.WaitGroup
+		for _, run := range queue {
+			select {
+			case <-ctx.Done():
+			case sem <- struct{}{}:
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			res, err := h.db.Exec("UPDATE workflow_runs SET status = 'running', started_at = NOW() WHERE id = ? AND status = 'pending'", run.id)
+			if err != nil {
+				<-sem
+				continue
+			}
+			if n, _ := res.RowsAffected(); n == 0 {
+				<-sem
+				continue
+			}
+			wg.Add(1)
+			go func(runID string, input json.RawMessage) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				h.executeWorkflow(runID, w, input, startNodeID)
+			}(run.id, run.input)
+		}
+		wg.Wait()
+
+		h.db.Exec("UPDATE backfills SET status = 'completed', finished_at = NOW() WHERE id = ? AND status = 'running'", backfillID)
+		log.Printf("📼 Backfill %s for workflow %s finished", backfillID, w.ID)
+	}()
+}
+
+// ResumeBackfills picks up the backfills that were running when the server
+// stopped: their pending runs are queued again, and runs that were in
+// progress are marked failed since their execution was lost.
+func (h *Handler) ResumeBackfills() {
+	rows, err := h.db.Query(`SELECT b.id, b.workflow_id, COALESCE(t.node_id, ''), b.max_concurrency
+		FROM backfills b LEFT JOIN workflow_triggers t ON t.id = b.trigger_id
+		WHERE b.status = 'running'`)
+	if err != nil {
+		log.Printf("Failed to load running backfills: %v", err)
+		return
+	}
+	type runningBackfill struct {
+		id, workflowID, startNodeID string
+		maxConcurrency              int
+	}
+	var backfills []runningBackfill
+	for rows.Next() {
+		var b runningBackfill
+		if rows.Scan(&b.id, &b.workflowID, &b.startNodeID, &b.maxConcurrency) == nil {
+			backfills = append(backfills, b)
+		}
+	}
+	rows.Close()
+
+	for _, b := range backfills {
+		var w Workflow
+		err := h.db.QueryRow("SELECT id, name, nodes, edges FROM workflows WHERE id = ?", b.workflowID).
+			Scan(&w.ID, &w.Name, &w.Nodes, &w.Edges)
+		if err != nil {
+			log.Printf("Failed to resume backfill %s: %v", b.id, err)
+			continue
+		}
+		h.db.Exec(
+			"UPDATE workflow_runs SET status = 'failed', message = 'Interrupted by a server restart', finished_at = NOW() WHERE backfill_id = ? AND status = 'running'",
+			b.id,
+		)
+
+		runRows, err := h.db.Query("SELECT id, input FROM workflow_runs WHERE backfill_id = ? AND status = 'pending' ORDER BY scheduled_for", b.id)
+		if err != nil {
+			log.Printf("Failed to resume backfill %s: %v", b.id, err)
+			continue
+		}
+		var queue []backfillRun
+		for runRows.Next() {
+			var run backfillRun
+			if runRows.Scan(&run.id, &run.input) == nil {
+				queue = append(queue, run)
+			}
+		}
+		runRows.Close()
+
+		log.Printf("📼 Resuming backfill %s for workflow '%s' (%d pending runs)", b.id, w.Name, len(queue))
+		h.runBackfill(b.id, w, b.startNodeID, queue, max(b.maxConcurrency, 1))
+	}
+}
+
+// resolveBackfillTrigger picks the schedule trigger to backfill. Without an
+// explicit trigger_id the workflow must have exactly one schedule trigger,
+// unless the request supplies its own cron_schedule.
+func (h *Handler) resolveBackfillTrigger(workflowID, triggerID string) (WorkflowTrigger, string) {
+	if triggerID != "" {
+		t, err := h.fetchTrigger(workflowID, triggerID)
+		if err != nil {
+			return WorkflowTrigger{}, "Trigger not found"
+		}
+		if t.Type != TriggerTypeSchedule {
+			return WorkflowTrigger{}, "Only schedule triggers can be backfilled"
+		}
+		return t, ""
+	}
+
+	rows, err := h.db.Query("SELECT "+triggerColumns+" FROM workflow_triggers WHERE workflow_id = ? AND type = 'schedule'", workflowID)
+	if err != nil {
+		return WorkflowTrigger{}, err.Error()
+	}
+	defer rows.Close()
+	var found []WorkflowTrigger
+	for rows.Next() {
+		if t, err := scanTrigger(rows); err == nil {
+			found = append(found, t)
+		}
+	}
+	if len(found) == 1 {
+		return found[0], ""
+	}
+	if len(found) > 1 {
+		return WorkflowTrigger{}, "Workflow has several schedule triggers; pass trigger_id"
+	}
+	return WorkflowTrigger{Input: json.RawMessage(`{}`)}, ""
+}
+
+const backfillColumns = "id, workflow_id, trigger_id, cron_schedule, start_time, end_time, max_concurrency, total_runs, status, created_at, finished_at"
+
+func scanBackfill(scanner interface{ Scan(...interface{}) error }) (Backfill, error) {
+	var b Backfill
+	err := scanner.Scan(&b.ID, &b.WorkflowID, &b.TriggerID, &b.CronSchedule, &b.StartTime, &b.EndTime, &b.MaxConcurrency, &b.TotalRuns, &b.Status, &b.CreatedAt, &b.FinishedAt)
+	return b, err
+}
+
+func (h *Handler) getWorkflowBackfills(c *gin.Context) {
+	rows, err := h.db.Query("SELECT "+backfillColumns+" FROM backfills WHERE workflow_id = ? ORDER BY created_at DESC", c.Param("id"))
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	backfills := []Backfill{}
+	for rows.Next() {
+		b, err := scanBackfill(rows)
+		if err != nil {
+			continue
+		}
+		backfills = append(backfills, b)
+	}
+	c.JSON(200, backfills)
+}
+
+// getBackfill returns a backfill with a count of its runs per status.
+func (h *Handler) getBackfill(c *gin.Context) {
+	b, err := scanBackfill(h.db.QueryRow("SELECT "+backfillColumns+" FROM backfills WHERE id = ?", c.Param("id")))
+	if err == sql.ErrNoRows {
+		c.JSON(404, gin.H{"error": "Backfill not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	b.RunCounts = map[string]int{}
+	rows, err := h.db.Query("SELECT status, COUNT(*) FROM workflow_runs WHERE backfill_id = ? GROUP BY status", b.ID)
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var status string
+			var n int
+			if rows.Scan(&status, &n) == nil {
+				b.RunCounts[status] = n
+			}
+		}
+	}
+	c.JSON(200, b)
+}
+
+// cancelBackfill stops queuing new runs and cancels the ones still pending.
+// Runs already in progress are left to finish.
+func (h *Handler) cancelBackfill(c *gin.Context) {
+	id := c.Param("id")
+	res, err := h.db.Exec("UPDATE backfills SET status = 'cancelled', finished_at = NOW() WHERE id = ? AND status = 'running'", id)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		c.JSON(409, gin.H{"error": "Backfill not found or not running"})
+		return
+	}
+
+	h.backfills.cancel(id)
+	res, err = h.db.Exec(
+		"UPDATE workflow_runs SET status = 'cancelled', message = 'Backfill cancelled', finished_at = NOW() WHERE backfill_id = ? AND status = 'pending'",
+		id,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	cancelled, _ := res.RowsAffected()
+	log.Printf("🛑 Backfill %s cancelled (%d pending runs dropped)", id, cancelled)
+	c.JSON(200, gin.H{"message": "Backfill cancelled", "cancelled_runs": cancelled})
+}
@@ -2,9 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
 	"log"
-	"net/http"
 	"sync"
 	"time"
 )
@@ -12,53 +10,62 @@ import (
 // ==================== Jira Account ID Cache ====================
 
 var (
-	jiraAccountIDCache  string
+	jiraAccountIDCache  map[string]bool
 	jiraAccountIDExpiry time.Time
 	jiraAccountIDMu     sync.Mutex
 )
 
-// getJiraAccountID returns the accountId of the Jira integration user, cached for 1 hour.
-func (h *Handler) getJiraAccountID() string {
+// isOwnJiraAccount reports whether accountID is the user of one of the jira
+// connections. The account IDs are cached for 1 hour, or a minute when none
+// could be fetched.
+func (h *Handler) isOwnJiraAccount(accountID string) bool {
 	jiraAccountIDMu.Lock()
 	defer jiraAccountIDMu.Unlock()
 
-	if jiraAccountIDCache != "" && time.Now().Before(jiraAccountIDExpiry) {
-		return jiraAccountIDCache
+	if jiraAccountIDCache == nil || time.Now().After(jiraAccountIDExpiry) {
+		jiraAccountIDCache = h.fetchJiraAccountIDs()
+		ttl := 1 * time.Hour
+		if len(jiraAccountIDCache) == 0 {
+			ttl = time.Minute
+		}
+		jiraAccountIDExpiry = time.Now().Add(ttl)
 	}
+	return jiraAccountIDCache[accountID]
+}
 
-	config, err := h.loadIntegrationConfig("jira")
+// fetchJiraAccountIDs asks each jira connection which user it acts as.
+func (h *Handler) fetchJiraAccountIDs() map[string]bool {
+	ids := map[string]bool{}
+	rows, err := h.db.Query("SELECT id FROM integrations WHERE type = 'jira'")
 	if err != nil {
-		return ""
+		return ids
 	}
-	domain, _ := config["domain"].(string)
-	email, _ := config["email"].(string)
-	token, _ := config["api_token"].(string)
-	if domain == "" || email == "" || token == "" {
-		return ""
+	var connections []string
+	for rows.Next() {
+		var id string
+		if rows.Scan(&id) == nil {
+			connections = append(connections, id)
+		}
 	}
+	rows.Close()
 
-	req, err := http.NewRequest("GET", fmt.Sprintf("https://%s/rest/api/3/myself", domain), nil)
-	if err != nil {
-		return ""
-	}
-	req.SetBasicAuth(email, token)
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return ""
+	for _, connectionID := range connections {
+		jc, err := h.jiraClientForConnection(connectionID)
+		if err != nil {
+			continue
+		}
+		body, err := jc.do("GET", "/rest/api/3/myself", nil)
+		if err != nil {
+			continue
+		}
+		var user struct {
+			AccountID string `json:"accountId"`
+		}
+		if err := json.Unmarshal(body, &user); err != nil || user.AccountID == "" {
+			continue
+		}
+		ids[user.AccountID] = true
+		log.Printf("🔑 Cached Jira account ID: %s (connection %s)", user.AccountID, connectionID)
 	}
-	defer resp.Body.Close()
-
-	var user struct {
-		AccountID string `json:"accountId"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil || user.AccountID == "" {
-		return ""
-	}
-
-	jiraAccountIDCache = user.AccountID
-	jiraAccountIDExpiry = time.Now().Add(1 * time.Hour)
-	log.Printf("🔑 Cached Jira account ID: %s", jiraAccountIDCache)
-	return jiraAccountIDCache
+	return ids
 }
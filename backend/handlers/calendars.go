@@ -0,0 +1,422 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ==================== Blackout Calendars ====================
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// validate checks a window's shape and returns a user-facing error.
+func (w BlackoutWindow) validate() error {
+	switch w.Type {
+	case "dates":
+		start, err := time.Parse("2006-01-02", w.Start)
+		if err != nil {
+			return fmt.Errorf("dates window: start must be YYYY-MM-DD")
+		}
+		if w.End != "" {
+			end, err := time.Parse("2006-01-02", w.End)
+			if err != nil {
+				return fmt.Errorf("dates window: end must be YYYY-MM-DD")
+			}
+			// end is inclusive, so a single day has end == start.
+			if end.Before(start) {
+				return fmt.Errorf("dates window: end is before start")
+			}
+		}
+	case "weekly":
+		if len(w.Days) == 0 {
+			return fmt.Errorf("weekly window: days is required")
+		}
+		for _, d := range w.Days {
+			if _, ok := weekdayNames[strings.ToLower(d)]; !ok {
+				return fmt.Errorf("weekly window: unknown day %q", d)
+			}
+		}
+		startMin, ok := parseClock(w.Start)
+		if !ok {
+			return fmt.Errorf("weekly window: start must be HH:MM")
+		}
+		endMin, ok := parseClock(w.End)
+		if !ok {
+			return fmt.Errorf("weekly window: end must be HH:MM")
+		}
+		// An end before start wraps past midnight; equal times are empty.
+		if endMin == startMin {
+			return fmt.Errorf("weekly window: end must differ from start")
+		}
+	case "range":
+		start, err := time.Parse("2006-01-02T15:04", w.Start)
+		if err != nil {
+			return fmt.Errorf("range window: start must be YYYY-MM-DDTHH:MM")
+		}
+		end, err := time.Parse("2006-01-02T15:04", w.End)
+		if err != nil {
+			return fmt.Errorf("range window: end must be YYYY-MM-DDTHH:MM")
+		}
+		if !end.After(start) {
+			return fmt.Errorf("range window: end must be after start")
+		}
+	default:
+		return fmt.Errorf("window type must be 'dates', 'weekly' or 'range'")
+	}
+	return nil
+}
+
+// parseClock parses HH:MM into minutes since midnight; "24:00" is allowed.
+func parseClock(s string) (int, bool) {
+	var hh, mm int
+	if _, err := fmt.Sscanf(s, "%d:%d", &hh, &mm); err != nil {
+		return 0, false
+	}
+	if hh < 0 || mm < 0 || mm > 59 || hh > 24 || (hh == 24 && mm != 0) {
+		return 0, false
+	}
+	return hh*60 + mm, true
+}
+
+// contains reports whether t (already in the calendar's zone) falls inside
+// the window.
+func (w BlackoutWindow) contains(t time.Time) bool {
+	loc := t.Location()
+	switch w.Type {
+	case "dates":
+		start, err := time.ParseInLocation("2006-01-02", w.Start, loc)
+		if err != nil {
+			return false
+		}
+		end := start
+		if w.End != "" {
+			if end, err = time.ParseInLocation("2006-01-02", w.End, loc); err != nil {
+				return false
+			}
+		}
+		return !t.Before(start) && t.Before(end.AddDate(0, 0, 1))
+	case "range":
+		start, err1 := time.ParseInLocation("2006-01-02T15:04", w.Start, loc)
+		end, err2 := time.ParseInLocation("2006-01-02T15:04", w.End, loc)
+		return err1 == nil && err2 == nil && !t.Before(start) && t.Before(end)
+	case "weekly":
+		startMin, ok1 := parseClock(w.Start)
+		endMin, ok2 := parseClock(w.End)
+		if !ok1 || !ok2 {
+			return false
+		}
+		minute := t.Hour()*60 + t.Minute()
+		for _, d := range w.Days {
+			day := weekdayNames[strings.ToLower(d)]
+			if endMin > startMin {
+				if t.Weekday() == day && minute >= startMin && minute < endMin {
+					return true
+				}
+				continue
+			}
+			// Wraps past midnight: the tail end falls on the following day.
+			if t.Weekday() == day && minute >= startMin {
+				return true
+			}
+			if t.Weekday() == (day+1)%7 && minute < endMin {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// blackoutAt returns the first window covering t, evaluated in the
+// calendar's timezone.
+func (cal ScheduleCalendar) blackoutAt(t time.Time) (BlackoutWindow, bool) {
+	loc, err := time.LoadLocation(cal.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := t.In(loc)
+	for _, w := range cal.Windows {
+		if w.contains(local) {
+			return w, true
+		}
+	}
+	return BlackoutWindow{}, false
+}
+
+func scanCalendar(scanner interface{ Scan(...interface{}) error }) (ScheduleCalendar, error) {
+	var cal ScheduleCalendar
+	var windowsJSON []byte
+	err := scanner.Scan(&cal.ID, &cal.Name, &cal.Timezone, &windowsJSON, &cal.CreatedAt, &cal.UpdatedAt)
+	cal.Windows = []BlackoutWindow{}
+	json.Unmarshal(windowsJSON, &cal.Windows)
+	return cal, err
+}
+
+func (h *Handler) fetchCalendar(id string) (ScheduleCalendar, error) {
+	row := h.db.QueryRow("SELECT id, name, timezone, windows, created_at, updated_at FROM schedule_calendars WHERE id = ?", id)
+	return scanCalendar(row)
+}
+
+func (h *Handler) getCalendars(c *gin.Context) {
+	rows, err := h.db.Query("SELECT id, name, timezone, windows, created_at, updated_at FROM schedule_calendars ORDER BY name")
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	calendars := []ScheduleCalendar{}
+	for rows.Next() {
+		cal, err := scanCalendar(rows)
+		if err != nil {
+			continue
+		}
+		calendars = append(calendars, cal)
+	}
+	c.JSON(200, calendars)
+}
+
+func (h *Handler) getCalendar(c *gin.Context) {
+	cal, err := h.fetchCalendar(c.Param("id"))
+	if err == sql.ErrNoRows {
+		c.JSON(404, gin.H{"error": "Calendar not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	// ?at=<RFC3339> answers "would a run at this instant be blacked out?"
+	if at := c.Query("at"); at != "" {
+		t, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "at must be an RFC3339 timestamp"})
+			return
+		}
+		w, blocked := cal.blackoutAt(t)
+		c.JSON(200, gin.H{"calendar": cal, "at": t, "blackout": blocked, "window": w})
+		return
+	}
+	c.JSON(200, cal)
+}
+
+type calendarRequest struct {
+	Name     string           `json:"name"`
+	Timezone string           `json:"timezone"`
+	Windows  []BlackoutWindow `json:"windows"`
+}
+
+func (req *calendarRequest) validate() string {
+	if req.Name == "" {
+		return "name is required"
+	}
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		return "Unknown timezone: " + req.Timezone
+	}
+	if req.Windows == nil {
+		req.Windows = []BlackoutWindow{}
+	}
+	for i, w := range req.Windows {
+		if err := w.validate(); err != nil {
+			return fmt.Sprintf("windows[%d]: %v", i, err)
+		}
+	}
+	return ""
+}
+
+func (h *Handler) createCalendar(c *gin.Context) {
+	var req calendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(400, gin.H{"error": msg})
+		return
+	}
+
+	id := uuid.New().String()
+	windowsJSON, _ := json.Marshal(req.Windows)
+	_, err := h.db.Exec(
+		"INSERT INTO schedule_calendars (id, name, timezone, windows) VALUES (?, ?, ?, ?)",
+		id, req.Name, req.Timezone, windowsJSON,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(201, gin.H{"id": id, "message": "Calendar created"})
+}
+
+func (h *Handler) updateCalendar(c *gin.Context) {
+	id := c.Param("id")
+	var req calendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(400, gin.H{"error": msg})
+		return
+	}
+
+	_, err := h.fetchCalendar(id)
+	if err == sql.ErrNoRows {
+		c.JSON(404, gin.H{"error": "Calendar not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	windowsJSON, _ := json.Marshal(req.Windows)
+	_, err = h.db.Exec(
+		"UPDATE schedule_calendars SET name = ?, timezone = ?, windows = ? WHERE id = ?",
+		req.Name, req.Timezone, windowsJSON, id,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"message": "Calendar updated"})
+}
+
+func (h *Handler) deleteCalendar(c *gin.Context) {
+	id := c.Param("id")
+	h.db.Exec("UPDATE workflow_triggers SET calendar_id = NULL WHERE calendar_id = ?", id)
+	_, err := h.db.Exec("DELETE FROM schedule_calendars WHERE id = ?", id)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"message": "Calendar deleted"})
+}
+
+// ==================== Schedule Pausing ====================
+
+// loadSchedulerPaused restores the global pause flag saved in the database.
+func (h *Handler) loadSchedulerPaused() {
+	var value string
+	if err := h.db.QueryRow("SELECT value FROM scheduler_settings WHERE name = 'paused'").Scan(&value); err != nil {
+		return
+	}
+	h.scheduler.setPaused(value == "true")
+}
+
+func (h *Handler) pauseScheduler(c *gin.Context)  { h.setSchedulerPaused(c, true) }
+func (h *Handler) resumeScheduler(c *gin.Context) { h.setSchedulerPaused(c, false) }
+
+func (h *Handler) setSchedulerPaused(c *gin.Context, paused bool) {
+	_, err := h.db.Exec(
+		"INSERT INTO scheduler_settings (name, value) VALUES ('paused', ?) ON DUPLICATE KEY UPDATE value = VALUES(value)",
+		fmt.Sprintf("%t", paused),
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	h.scheduler.setPaused(paused)
+	log.Printf("⏸️ Scheduler paused=%t", paused)
+	c.JSON(200, gin.H{"paused": paused})
+}
+
+func (h *Handler) pauseWorkflowTrigger(c *gin.Context)  { h.setTriggerPaused(c, true) }
+func (h *Handler) resumeWorkflowTrigger(c *gin.Context) { h.setTriggerPaused(c, false) }
+
+func (h *Handler) setTriggerPaused(c *gin.Context, paused bool) {
+	t, err := h.fetchTrigger(c.Param("id"), c.Param("triggerId"))
+	if err == sql.ErrNoRows {
+		c.JSON(404, gin.H{"error": "Trigger not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if t.Type != TriggerTypeSchedule {
+		c.JSON(400, gin.H{"error": "Only schedule triggers can be paused"})
+		return
+	}
+	if _, err := h.db.Exec("UPDATE workflow_triggers SET paused = ? WHERE id = ?", paused, t.ID); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"trigger_id": t.ID, "paused": paused})
+}
+
+// scheduleSkipReason decides whether a due tick should be skipped, returning
+// the reason, a human-readable detail and the calendar responsible.
+func (h *Handler) scheduleSkipReason(t WorkflowTrigger, fireTime time.Time) (string, string, *string) {
+	if h.scheduler.isPaused() {
+		return "scheduler_paused", "all schedules are paused", nil
+	}
+	if t.Paused {
+		return "trigger_paused", "schedule is paused", nil
+	}
+	if t.CalendarID != nil {
+		cal, err := h.fetchCalendar(*t.CalendarID)
+		if err != nil {
+			return "", "", nil
+		}
+		if w, blocked := cal.blackoutAt(fireTime); blocked {
+			detail := fmt.Sprintf("%s: %s window %s–%s", cal.Name, w.Type, w.Start, w.End)
+			if w.Label != "" {
+				detail = fmt.Sprintf("%s: %s", cal.Name, w.Label)
+			}
+			return "blackout", detail, t.CalendarID
+		}
+	}
+	return "", "", nil
+}
+
+// recordScheduleSkip logs a tick that did not start a run.
+func (h *Handler) recordScheduleSkip(t WorkflowTrigger, fireTime time.Time, reason, detail string, calendarID *string) {
+	log.Printf("⏭️ Skipping scheduled run of workflow %s (trigger=%s): %s — %s", t.WorkflowID, t.ID, reason, detail)
+	h.db.Exec(
+		"INSERT INTO schedule_skips (id, trigger_id, workflow_id, fire_time, reason, detail, calendar_id) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		uuid.New().String(), t.ID, t.WorkflowID, fireTime, reason, detail, calendarID,
+	)
+}
+
+// getScheduleSkips lists recent skipped ticks for a workflow, optionally
+// narrowed to one trigger with ?trigger_id=.
+func (h *Handler) getScheduleSkips(c *gin.Context) {
+	query := "SELECT id, trigger_id, workflow_id, fire_time, reason, detail, calendar_id, created_at FROM schedule_skips WHERE workflow_id = ?"
+	args := []interface{}{c.Param("id")}
+	if triggerID := c.Query("trigger_id"); triggerID != "" {
+		query += " AND trigger_id = ?"
+		args = append(args, triggerID)
+	}
+	query += " ORDER BY fire_time DESC LIMIT 100"
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	skips := []ScheduleSkip{}
+	for rows.Next() {
+		var s ScheduleSkip
+		if err := rows.Scan(&s.ID, &s.TriggerID, &s.WorkflowID, &s.FireTime, &s.Reason, &s.Detail, &s.CalendarID, &s.CreatedAt); err != nil {
+			continue
+		}
+		skips = append(skips, s)
+	}
+	c.JSON(200, skips)
+}
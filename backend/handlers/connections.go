@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+)
+
+// ==================== Connections ====================
+//
+// A connection is one configured account of an integration type, e.g. one
+// of two Jira sites. Nodes pick one with their connection_id field; without
+// it they use the type's default connection.
+
+// errConnectionNameTaken is returned when a type already has a connection
+// with the requested name.
+var errConnectionNameTaken = errors.New("a connection with this name already exists for this type")
+
+// loadConnectionConfig loads the decrypted config of a connection of the
+// given type: the one with connectionID, or the type's default when empty.
+// OAuth connections come with a fresh access token.
+func (h *Handler) loadConnectionConfig(iType, connectionID string) (map[string]interface{}, error) {
+	i, config, err := h.storedConnectionConfig(iType, connectionID)
+	if err != nil {
+		return nil, err
+	}
+	config, err = h.ensureOAuthToken(i, config)
+	if err != nil {
+		return nil, &oauthTokenError{err}
+	}
+	return config, nil
+}
+
+// storedConnectionConfig loads a connection and its decrypted config as
+// stored, without refreshing OAuth tokens.
+func (h *Handler) storedConnectionConfig(iType, connectionID string) (Integration, map[string]interface{}, error) {
+	var i Integration
+	var err error
+	if connectionID == "" {
+		i, err = h.defaultConnection(iType)
+	} else {
+		i, err = h.scanIntegration(h.db.QueryRow("SELECT "+integrationColumns+" FROM integrations WHERE id = ? AND type = ?", connectionID, iType))
+	}
+	if err != nil {
+		return i, nil, err
+	}
+	var config map[string]interface{}
+	if err := json.Unmarshal(i.Config, &config); err != nil {
+		return i, nil, err
+	}
+	return i, config, nil
+}
+
+// nodeConnectionID returns the connection chosen on a node, templated
+// against the input so it can vary per run.
+func nodeConnectionID(data, input map[string]interface{}) string {
+	id, _ := data["connection_id"].(string)
+	return strings.TrimSpace(templateReplace(id, input))
+}
+
+// connectionError turns a failure to load a node's connection into the
+// message shown on the node.
+func connectionError(label, connectionID string, err error) string {
+	var tokenErr *oauthTokenError
+	if errors.As(err, &tokenErr) {
+		return fmt.Sprintf("%s connection has no valid OAuth token: %v", label, tokenErr.err)
+	}
+	if connectionID != "" && err == sql.ErrNoRows {
+		return fmt.Sprintf("%s connection %q not found. Go to Settings → Integrations to check it.", label, connectionID)
+	}
+	return fmt.Sprintf("%s integration not configured. Go to Settings → Integrations to set it up.", label)
+}
+
+// insertConnection stores a new connection. The first connection of a type
+// always becomes its default.
+func (h *Handler) insertConnection(id, iType, name string, config json.RawMessage, makeDefault bool) (int, error) {
+	merged, err := mergeSecretConfig(iType, nil, config)
+	if err != nil {
+		return 400, err
+	}
+	sealed, err := h.keys.seal(id, merged)
+	if err != nil {
+		return 500, err
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return 500, err
+	}
+	defer tx.Rollback()
+
+	var others int
+	tx.QueryRow("SELECT COUNT(*) FROM integrations WHERE type = ? FOR UPDATE", iType).Scan(&others)
+	if others == 0 {
+		makeDefault = true
+	}
+	if makeDefault {
+		if _, err := tx.Exec("UPDATE integrations SET is_default = FALSE WHERE type = ?", iType); err != nil {
+			return 500, err
+		}
+	}
+	_, err = tx.Exec("INSERT INTO integrations (id, type, name, is_default, "+integrationConfigColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		append([]interface{}{id, iType, name, makeDefault}, sealed.values()...)...)
+	if err != nil {
+		return connectionWriteStatus(err)
+	}
+	return 200, tx.Commit()
+}
+
+// updateConnectionConfig saves a connection's name and config. Secrets left
+// out of config, or sent back masked, keep their stored values as long as
+// the host fields (domain, site, token_url, userinfo_url) are unchanged.
+func (h *Handler) updateConnectionConfig(existing Integration, name string, config json.RawMessage) (int, error) {
+	merged, err := mergeSecretConfig(existing.Type, existing.Config, config)
+	if err != nil {
+		return 400, err
+	}
+	sealed, err := h.keys.seal(existing.ID, keepOAuthState(existing.Config, merged))
+	if err != nil {
+		return 500, err
+	}
+	_, err = h.db.Exec("UPDATE integrations SET name = ?, config = ?, config_key_id = ?, config_dek = ?, config_ciphertext = ? WHERE id = ?",
+		append(append([]interface{}{name}, sealed.values()...), existing.ID)...)
+	if err != nil {
+		return connectionWriteStatus(err)
+	}
+	return 200, nil
+}
+
+// connectionWriteStatus maps a write error to a response status.
+func connectionWriteStatus(err error) (int, error) {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		return 409, errConnectionNameTaken
+	}
+	return 500, err
+}
+
+// setDefaultConnection makes a connection the default of its type.
+func (h *Handler) setDefaultConnection(id, iType string) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("UPDATE integrations SET is_default = FALSE WHERE type = ?", iType); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE integrations SET is_default = TRUE WHERE id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// removeConnection deletes a connection. When it was the default, the
+// oldest remaining connection of the type takes over.
+func (h *Handler) removeConnection(i Integration) error {
+	if _, err := h.db.Exec("DELETE FROM integrations WHERE id = ?", i.ID); err != nil {
+		return err
+	}
+	if i.IsDefault {
+		var next string
+		if h.db.QueryRow("SELECT id FROM integrations WHERE type = ? ORDER BY created_at LIMIT 1", i.Type).Scan(&next) == nil {
+			return h.setDefaultConnection(next, i.Type)
+		}
+	}
+	return nil
+}
+
+// findConnection loads the connection named by the :id path parameter,
+// writing a 404 or 500 response when it cannot.
+func (h *Handler) findConnection(c *gin.Context) (Integration, bool) {
+	i, err := h.scanIntegration(h.db.QueryRow("SELECT "+integrationColumns+" FROM integrations WHERE id = ?", c.Param("id")))
+	if err == sql.ErrNoRows {
+		c.JSON(404, gin.H{"error": "Connection not found"})
+		return i, false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return i, false
+	}
+	return i, true
+}
+
+// getConnections lists connections, optionally of one type (?type=jira).
+func (h *Handler) getConnections(c *gin.Context) {
+	query := "SELECT " + integrationColumns + " FROM integrations"
+	var args []interface{}
+	if t := c.Query("type"); t != "" {
+		query += " WHERE type = ?"
+		args = append(args, t)
+	}
+	rows, err := h.db.Query(query+" ORDER BY type, is_default DESC, name", args...)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	connections := []Integration{}
+	for rows.Next() {
+		i, err := h.scanIntegration(rows)
+		if err != nil {
+			continue
+		}
+		maskIntegration(&i)
+		connections = append(connections, i)
+	}
+	c.JSON(200, connections)
+}
+
+func (h *Handler) getConnection(c *gin.Context) {
+	i, ok := h.findConnection(c)
+	if !ok {
+		return
+	}
+	maskIntegration(&i)
+	c.JSON(200, i)
+}
+
+func (h *Handler) createConnection(c *gin.Context) {
+	var req struct {
+		Type      string          `json:"type" binding:"required"`
+		Name      string          `json:"name" binding:"required"`
+		Config    json.RawMessage `json:"config"`
+		IsDefault bool            `json:"is_default"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	id := uuid.New().String()
+	if status, err := h.insertConnection(id, req.Type, req.Name, req.Config, req.IsDefault); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(201, h.withSaveTest(c, id, gin.H{"id": id, "message": "Connection created"}))
+}
+
+func (h *Handler) updateConnection(c *gin.Context) {
+	i, ok := h.findConnection(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Name      string          `json:"name"`
+		Config    json.RawMessage `json:"config"`
+		IsDefault *bool           `json:"is_default"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Name == "" {
+		req.Name = i.Name
+	}
+	if len(req.Config) == 0 {
+		// Renaming or making default leaves the config as it is.
+		if _, err := h.db.Exec("UPDATE integrations SET name = ? WHERE id = ?", req.Name, i.ID); err != nil {
+			status, err := connectionWriteStatus(err)
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+	} else if status, err := h.updateConnectionConfig(i, req.Name, req.Config); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if req.IsDefault != nil && *req.IsDefault && !i.IsDefault {
+		if err := h.setDefaultConnection(i.ID, i.Type); err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.JSON(200, h.withSaveTest(c, i.ID, gin.H{"id": i.ID, "message": "Connection updated"}))
+}
+
+func (h *Handler) makeDefaultConnection(c *gin.Context) {
+	i, ok := h.findConnection(c)
+	if !ok {
+		return
+	}
+	if err := h.setDefaultConnection(i.ID, i.Type); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"id": i.ID, "message": "Default connection updated"})
+}
+
+func (h *Handler) deleteConnection(c *gin.Context) {
+	i, ok := h.findConnection(c)
+	if !ok {
+		return
+	}
+	if err := h.removeConnection(i); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"message": "Connection deleted"})
+}
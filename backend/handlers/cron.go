@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==================== Cron Expressions ====================
+
+// cronSchedule computes the next fire time strictly after a given instant.
+type cronSchedule interface {
+	next(after time.Time) time.Time
+}
+
+// everySchedule fires at a fixed interval (@every 30s, @every 5m).
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) next(after time.Time) time.Time {
+	return after.Add(s.interval).Truncate(time.Second)
+}
+
+// fieldSchedule is a parsed 5-field (min hour dom month dow) or 6-field
+// (sec min hour dom month dow) cron expression. Each field is a bitmask of
+// allowed values.
+type fieldSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+	domStar, dowStar                      bool
+}
+
+type cronFieldBounds struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	cronSecondBounds = cronFieldBounds{0, 59, nil}
+	cronMinuteBounds = cronFieldBounds{0, 59, nil}
+	cronHourBounds   = cronFieldBounds{0, 23, nil}
+	cronDomBounds    = cronFieldBounds{1, 31, nil}
+	cronMonthBounds  = cronFieldBounds{1, 12, map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	cronDowBounds = cronFieldBounds{0, 7, map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// cronDescriptors maps the @-shorthands to their 6-field equivalents.
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// parseCronSchedule parses a cron expression. Supported forms:
+//   - @every <duration> (minimum 1s)
+//   - @yearly, @monthly, @weekly, @daily, @hourly
+//   - 5 fields: min hour dom month dow
+//   - 6 fields: sec min hour dom month dow
+//
+// Fields accept *, numbers, names (jan, mon), ranges (1-5), lists (1,3,5)
+// and steps (*/15, 10-30/5).
+func parseCronSchedule(expr string) (cronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("empty cron expression")
+	}
+
+	if strings.HasPrefix(expr, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid @every duration: %v", err)
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("@every interval must be at least 1s")
+		}
+		return everySchedule{interval: d}, nil
+	}
+	if full, ok := cronDescriptors[expr]; ok {
+		expr = full
+	}
+
+	fields := strings.Fields(expr)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("expected 5 or 6 fields, got %d", len(fields))
+	}
+
+	var s fieldSchedule
+	var err error
+	if s.second, err = parseCronField(fields[0], cronSecondBounds); err != nil {
+		return nil, fmt.Errorf("second: %v", err)
+	}
+	if s.minute, err = parseCronField(fields[1], cronMinuteBounds); err != nil {
+		return nil, fmt.Errorf("minute: %v", err)
+	}
+	if s.hour, err = parseCronField(fields[2], cronHourBounds); err != nil {
+		return nil, fmt.Errorf("hour: %v", err)
+	}
+	if s.dom, err = parseCronField(fields[3], cronDomBounds); err != nil {
+		return nil, fmt.Errorf("day of month: %v", err)
+	}
+	if s.month, err = parseCronField(fields[4], cronMonthBounds); err != nil {
+		return nil, fmt.Errorf("month: %v", err)
+	}
+	if s.dow, err = parseCronField(fields[5], cronDowBounds); err != nil {
+		return nil, fmt.Errorf("day of week: %v", err)
+	}
+	// Sunday may be written as 0 or 7.
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = fields[3] == "*" || fields[3] == "?"
+	s.dowStar = fields[5] == "*" || fields[5] == "?"
+	return s, nil
+}
+
+func parseCronField(field string, b cronFieldBounds) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(field, ",") {
+		if part == "" {
+			return 0, fmt.Errorf("empty list element in %q", field)
+		}
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			rangePart = part[:i]
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			step = n
+		}
+
+		lo, hi := b.min, b.max
+		switch {
+		case rangePart == "*" || rangePart == "?":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if lo, err = parseCronValue(bounds[0], b); err != nil {
+				return 0, err
+			}
+			if hi, err = parseCronValue(bounds[1], b); err != nil {
+				return 0, err
+			}
+		default:
+			v, err := parseCronValue(rangePart, b)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			// "5/10" means starting at 5 every 10; a bare "5" is just 5.
+			if !strings.Contains(part, "/") {
+				hi = v
+			}
+		}
+		if lo > hi {
+			return 0, fmt.Errorf("range %q is backwards", rangePart)
+		}
+		for v := lo; v <= hi; v += step {
+			mask |= 1 << uint(v)
+		}
+	}
+	return mask, nil
+}
+
+func parseCronValue(s string, b cronFieldBounds) (int, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < b.min || v > b.max {
+		return 0, fmt.Errorf("value %d out of range [%d,%d]", v, b.min, b.max)
+	}
+	return v, nil
+}
+
+// next walks forward field by field, resetting lower fields whenever a higher
+// one is advanced. Gives up (returning the zero time) after five years, which
+// only happens for impossible dates such as 30 February.
+func (s fieldSchedule) next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Add(time.Second - time.Duration(after.Nanosecond())*time.Nanosecond)
+	limit := t.Year() + 5
+
+WRAP:
+	if t.Year() > limit {
+		return time.Time{}
+	}
+
+	for s.month&(1<<uint(t.Month())) == 0 {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		if t.Month() == time.January {
+			goto WRAP
+		}
+	}
+
+	for !s.dayMatches(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		if t.Day() == 1 {
+			goto WRAP
+		}
+	}
+
+	for s.hour&(1<<uint(t.Hour())) == 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		if t.Hour() == 0 {
+			goto WRAP
+		}
+	}
+
+	for s.minute&(1<<uint(t.Minute())) == 0 {
+		t = t.Truncate(time.Minute).Add(time.Minute)
+		if t.Minute() == 0 {
+			goto WRAP
+		}
+	}
+
+	for s.second&(1<<uint(t.Second())) == 0 {
+		t = t.Truncate(time.Second).Add(time.Second)
+		if t.Second() == 0 {
+			goto WRAP
+		}
+	}
+
+	return t
+}
+
+// dayMatches applies the classic cron rule: when both day-of-month and
+// day-of-week are restricted, a day matches if either does.
+func (s fieldSchedule) dayMatches(t time.Time) bool {
+	domOK := s.dom&(1<<uint(t.Day())) != 0
+	dowOK := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
+
+// cronFireTimes enumerates fire times in the half-open range (from, to],
+// stopping after limit entries.
+func cronFireTimes(sched cronSchedule, from, to time.Time, limit int) []time.Time {
+	var times []time.Time
+	for t := sched.next(from); !t.IsZero() && !t.After(to); t = sched.next(t) {
+		times = append(times, t)
+		if len(times) >= limit {
+			break
+		}
+	}
+	return times
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Friday 15 March 2024, 10:20:30 UTC.
+var cronEpoch = time.Date(2024, 3, 15, 10, 20, 30, 0, time.UTC)
+
+func TestCronScheduleFireTimes(t *testing.T) {
+	const layout = "Mon 2006-01-02 15:04:05"
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"* * * * *", []string{"Fri 2024-03-15 10:21:00", "Fri 2024-03-15 10:22:00", "Fri 2024-03-15 10:23:00"}},
+		{"*/20 * * * * *", []string{"Fri 2024-03-15 10:20:40", "Fri 2024-03-15 10:21:00", "Fri 2024-03-15 10:21:20"}},
+		{"*/15 * * * *", []string{"Fri 2024-03-15 10:30:00", "Fri 2024-03-15 10:45:00", "Fri 2024-03-15 11:00:00"}},
+		{"10-30/10 * * * *", []string{"Fri 2024-03-15 10:30:00", "Fri 2024-03-15 11:10:00", "Fri 2024-03-15 11:20:00"}},
+		{"50/5 * * * *", []string{"Fri 2024-03-15 10:50:00", "Fri 2024-03-15 10:55:00", "Fri 2024-03-15 11:50:00"}},
+		{"0 8,12,18 * * *", []string{"Fri 2024-03-15 12:00:00", "Fri 2024-03-15 18:00:00", "Sat 2024-03-16 08:00:00"}},
+		{"0 9 * * 1-5", []string{"Mon 2024-03-18 09:00:00", "Tue 2024-03-19 09:00:00", "Wed 2024-03-20 09:00:00"}},
+		{"0 9 * * MON,fri", []string{"Mon 2024-03-18 09:00:00", "Fri 2024-03-22 09:00:00", "Mon 2024-03-25 09:00:00"}},
+		// Sunday is 0 or 7.
+		{"0 0 * * 0", []string{"Sun 2024-03-17 00:00:00", "Sun 2024-03-24 00:00:00"}},
+		{"0 0 * * 7", []string{"Sun 2024-03-17 00:00:00", "Sun 2024-03-24 00:00:00"}},
+		// Day 31 skips the months without one.
+		{"0 0 31 * *", []string{"Sun 2024-03-31 00:00:00", "Fri 2024-05-31 00:00:00", "Wed 2024-07-31 00:00:00"}},
+		{"0 0 29 feb *", []string{"Tue 2028-02-29 00:00:00"}},
+		{"0 0 1 jan,Jul *", []string{"Mon 2024-07-01 00:00:00", "Wed 2025-01-01 00:00:00"}},
+		// With both day fields restricted either one matches...
+		{"0 0 20 * mon", []string{"Mon 2024-03-18 00:00:00", "Wed 2024-03-20 00:00:00", "Mon 2024-03-25 00:00:00"}},
+		// ...but a * (or ?) day field does not widen the other.
+		{"0 0 20 * *", []string{"Wed 2024-03-20 00:00:00", "Sat 2024-04-20 00:00:00"}},
+		{"0 0 20 * ?", []string{"Wed 2024-03-20 00:00:00", "Sat 2024-04-20 00:00:00"}},
+		{"@hourly", []string{"Fri 2024-03-15 11:00:00", "Fri 2024-03-15 12:00:00"}},
+		{"@daily", []string{"Sat 2024-03-16 00:00:00", "Sun 2024-03-17 00:00:00"}},
+		{"@weekly", []string{"Sun 2024-03-17 00:00:00", "Sun 2024-03-24 00:00:00"}},
+		{"@monthly", []string{"Mon 2024-04-01 00:00:00", "Wed 2024-05-01 00:00:00"}},
+		{"@yearly", []string{"Wed 2025-01-01 00:00:00", "Thu 2026-01-01 00:00:00"}},
+		{"@every 90s", []string{"Fri 2024-03-15 10:22:00", "Fri 2024-03-15 10:23:30"}},
+	}
+	for _, tt := range tests {
+		sched, err := parseCronSchedule(tt.expr)
+		if err != nil {
+			t.Errorf("parseCronSchedule(%q): %v", tt.expr, err)
+			continue
+		}
+		var got []string
+		for _, ft := range cronFireTimes(sched, cronEpoch, cronEpoch.AddDate(5, 0, 0), len(tt.want)) {
+			got = append(got, ft.Format(layout))
+		}
+		if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+			t.Errorf("%q fires at\n  %s\nwant\n  %s", tt.expr, strings.Join(got, ", "), strings.Join(tt.want, ", "))
+		}
+	}
+}
+
+func TestCronFireTimesRange(t *testing.T) {
+	sched, _ := parseCronSchedule("0 */6 * * *")
+	from := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+
+	// The range is (from, to]: midnight at the start is excluded, at the end included.
+	got := cronFireTimes(sched, from, to, 100)
+	if len(got) != 4 || !got[0].Equal(from.Add(6*time.Hour)) || !got[3].Equal(to) {
+		t.Errorf("fire times in (from, to] = %v", got)
+	}
+	if got := cronFireTimes(sched, from, to, 2); len(got) != 2 {
+		t.Errorf("limit 2 returned %d fire times", len(got))
+	}
+}
+
+func TestCronScheduleKeepsLocation(t *testing.T) {
+	berlin := time.FixedZone("CET", 3600)
+	sched, _ := parseCronSchedule("30 9 * * *")
+	got := sched.next(time.Date(2024, 3, 15, 9, 30, 0, 0, berlin))
+	if want := time.Date(2024, 3, 16, 9, 30, 0, 0, berlin); !got.Equal(want) || got.Location() != berlin {
+		t.Errorf("next = %v, want %v", got, want)
+	}
+}
+
+func TestCronScheduleImpossibleDate(t *testing.T) {
+	sched, _ := parseCronSchedule("0 0 30 2 *")
+	if got := sched.next(cronEpoch); !got.IsZero() {
+		t.Errorf("30 February fires at %v, want never", got)
+	}
+}
+
+func TestParseCronScheduleErrors(t *testing.T) {
+	for expr, want := range map[string]string{
+		"":               "empty cron expression",
+		"* * * *":        "expected 5 or 6 fields, got 4",
+		"* * * * * * *":  "expected 5 or 6 fields, got 7",
+		"@fortnightly":   "expected 5 or 6 fields, got 1",
+		"@every soon":    "invalid @every duration",
+		"@every 500ms":   "@every interval must be at least 1s",
+		"60 * * * * *":   "second: value 60 out of range [0,59]",
+		"60 * * * *":     "minute: value 60 out of range [0,59]",
+		"* 24 * * *":     "hour: value 24 out of range [0,23]",
+		"* * 0 * *":      "day of month: value 0 out of range [1,31]",
+		"* * * 13 *":     "month: value 13 out of range [1,12]",
+		"* * * smarch *": `month: invalid value "smarch"`,
+		"* * * * 8":      "day of week: value 8 out of range [0,7]",
+		"30-10 * * * *":  `minute: range "30-10" is backwards`,
+		"*/0 * * * *":    `minute: invalid step in "*/0"`,
+		"1,,2 * * * *":   `minute: empty list element in "1,,2"`,
+	} {
+		_, err := parseCronSchedule(expr)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("parseCronSchedule(%q) error = %v, want %q", expr, err, want)
+		}
+	}
+}
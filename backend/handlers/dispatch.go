@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+)
+
+// ==================== Event Trigger Dispatch ====================
+
+// triggerMatcher applies a trigger node's filters to an inbound event and
+// returns a reason when they reject it.
+type triggerMatcher func(data map[string]interface{}) (bool, string)
+
+// matchesFilterList reports whether value matches a comma-separated list of
+// values or glob patterns (e.g. "main, release/*"), case-insensitively. An
+// empty filter matches everything.
+func matchesFilterList(filter, value string) bool {
+	if strings.TrimSpace(filter) == "" {
+		return true
+	}
+	value = strings.ToLower(value)
+	for _, f := range strings.Split(filter, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" {
+			continue
+		}
+		if ok, err := path.Match(f, value); f == value || (err == nil && ok) {
+			return true
+		}
+	}
+	return false
+}
+
+// dispatchEvent evaluates the nodeType trigger nodes of every active
+// workflow, or only of workflowID (whatever its status) when set, and starts
+// a run in each workflow with a matching node. It returns the evaluation of
+// every node it looked at.
+func (h *Handler) dispatchEvent(eventID, nodeType string, payload []byte, workflowID string, opts runOptions, matches triggerMatcher) []TriggerMatch {
+	query := "SELECT id, name, nodes, edges FROM workflows WHERE status = 'active'"
+	var args []interface{}
+	if workflowID != "" {
+		query = "SELECT id, name, nodes, edges FROM workflows WHERE id = ?"
+		args = append(args, workflowID)
+	}
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		log.Printf("Failed to query workflows for webhook trigger: %v", err)
+		return nil
+	}
+	var workflows []Workflow
+	for rows.Next() {
+		var w Workflow
+		if err := rows.Scan(&w.ID, &w.Name, &w.Nodes, &w.Edges); err != nil {
+			continue
+		}
+		workflows = append(workflows, w)
+	}
+	rows.Close()
+
+	results := []TriggerMatch{}
+	for i := range workflows {
+		results = append(results, h.triggerWorkflowNodes(&workflows[i], eventID, nodeType, payload, opts, matches)...)
+	}
+	return results
+}
+
+// triggerWorkflowNodes starts one run for the first nodeType node of the
+// workflow whose filters accept the event and whose trigger is enabled.
+func (h *Handler) triggerWorkflowNodes(w *Workflow, eventID, nodeType string, payload []byte, opts runOptions, matches triggerMatcher) []TriggerMatch {
+	var nodes []map[string]interface{}
+	if err := json.Unmarshal(w.Nodes, &nodes); err != nil {
+		return nil
+	}
+
+	var parsed map[string]interface{}
+	json.Unmarshal(payload, &parsed)
+
+	var results []TriggerMatch
+	for _, node := range nodes {
+		if t, _ := node["type"].(string); t != nodeType {
+			continue
+		}
+
+		nodeID, _ := node["id"].(string)
+		match := TriggerMatch{WorkflowID: w.ID, NodeID: nodeID}
+		data, _ := node["data"].(map[string]interface{})
+		if ok, reason := matches(data); !ok {
+			match.Reason = reason
+			results = append(results, match)
+			continue
+		}
+		if !applyFilterExpression(data, parsed, &match) {
+			results = append(results, match)
+			continue
+		}
+
+		// Each node gets its own options so nothing set for a skipped node
+		// carries over to the next one.
+		nodeOpts := opts
+		nodeOpts.StartNodeID = nodeID
+		input := json.RawMessage(payload)
+		mapped, err := applyInputMapping(data, parsed)
+		if err != nil {
+			match.Reason = "invalid input mapping: " + err.Error()
+			results = append(results, match)
+			continue
+		}
+		if mapped != nil {
+			// Slack Message's reply_in_thread reads the event context, so
+			// it is kept unless the mapping sets "slack" itself.
+			if slackCtx, ok := parsed["slack"]; ok && nodeType == "slack_trigger" {
+				base, _ := json.Marshal(map[string]interface{}{"slack": slackCtx})
+				mapped = mergeJSONObjects(base, mapped)
+			}
+			input = mapped
+			nodeOpts.TriggerPayload = payload
+		}
+		if t, ok := h.findNodeTrigger(w.ID, nodeID); ok {
+			if !t.Enabled {
+				match.Reason = "trigger is disabled"
+				results = append(results, match)
+				continue
+			}
+			nodeOpts.TriggerID = &t.ID
+			input = mergeJSONObjects(t.Input, input)
+		}
+
+		log.Printf("🚀 Triggering workflow '%s' (id=%s) from %s node %s", w.Name, w.ID, nodeType, nodeID)
+
+		runID, err := h.startRun(*w, input, nodeOpts)
+		if err != nil {
+			log.Printf("Failed to start run for workflow %s: %v", w.ID, err)
+			match.Reason = "failed to start run: " + err.Error()
+			return append(results, match)
+		}
+		if nodeOpts.TriggerID != nil {
+			h.markTriggerFired(*nodeOpts.TriggerID)
+		}
+		h.linkEventRun(eventID, runID, w.ID, nodeOpts)
+		match.Matched = true
+		match.RunID = runID
+		return append(results, match)
+	}
+	return results
+}
+
+// applyFilterExpression evaluates the node's filter_expression, if any, and
+// records the outcome on the match. It reports whether the event passes.
+func applyFilterExpression(data, payload map[string]interface{}, match *TriggerMatch) bool {
+	expr, _ := data["filter_expression"].(string)
+	if strings.TrimSpace(expr) == "" {
+		return true
+	}
+	passed, reason := evalFilterExpression(expr, payload)
+	match.Filter = &FilterResult{Expression: expr, Passed: passed, Reason: reason}
+	if !passed {
+		match.Reason = reason
+	}
+	return passed
+}
+
+// recordMatchResults stores how each trigger node evaluated an event and,
+// when none started a run, a summary of why the event was skipped.
+func (h *Handler) recordMatchResults(eventID string, results []TriggerMatch) {
+	var skipReason *string
+	matched := false
+	for _, m := range results {
+		matched = matched || m.Matched
+	}
+	if !matched {
+		reason := "no active workflow listens for this event"
+		if len(results) == 1 {
+			reason = results[0].Reason
+		} else if len(results) > 1 {
+			reason = fmt.Sprintf("none of %d triggers matched", len(results))
+		}
+		skipReason = &reason
+		log.Printf("⏭️ Webhook event %s skipped: %s", eventID, reason)
+	}
+	resultsJSON, _ := json.Marshal(results)
+	h.db.Exec("UPDATE webhook_events SET match_results = ?, skip_reason = ? WHERE id = ?", resultsJSON, skipReason, eventID)
+}
+
+// linkEventRun records that an event started a run. workflow_run_id keeps
+// the first run so older clients still see one.
+func (h *Handler) linkEventRun(eventID, runID, workflowID string, opts runOptions) {
+	_, err := h.db.Exec(
+		"INSERT INTO webhook_event_runs (event_id, workflow_run_id, workflow_id, trigger_id, replay) VALUES (?, ?, ?, ?, ?)",
+		eventID, runID, workflowID, opts.TriggerID, opts.ReplayOf != nil,
+	)
+	if err != nil {
+		log.Printf("Failed to link webhook event %s to run %s: %v", eventID, runID, err)
+	}
+	h.db.Exec("UPDATE webhook_events SET processed = TRUE, workflow_run_id = COALESCE(workflow_run_id, ?) WHERE id = ?", runID, eventID)
+}
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// ==================== Integration Config Encryption ====================
+//
+// Integration configs are sealed with envelope encryption: each row gets a
+// random data key that encrypts the config with AES-256-GCM, and the data key
+// is itself encrypted ("wrapped") with a master key. The master key's ID is
+// stored on the row so keys can be rotated without downtime.
+//
+// Master keys come from INTEGRATION_KEYS, or one per line from the file named
+// by INTEGRATION_KEYS_FILE, as "id:base64-32-byte-key". The first key
+// encrypts; the others only decrypt rows that have not been rotated yet.
+// Without keys, configs are stored as plain JSON.
+
+const dataKeySize = 32
+
+// keyring holds the master keys by ID.
+type keyring struct {
+	activeID string
+	keys     map[string][]byte
+}
+
+// loadKeyring reads the master keys from the environment. It returns nil,
+// nil when none are configured.
+func loadKeyring() (*keyring, error) {
+	raw := os.Getenv("INTEGRATION_KEYS")
+	if raw == "" {
+		if path := os.Getenv("INTEGRATION_KEYS_FILE"); path != "" {
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("read INTEGRATION_KEYS_FILE: %w", err)
+			}
+			raw = string(b)
+		}
+	}
+
+	k := &keyring{keys: map[string][]byte{}}
+	for _, entry := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == '\n' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		id = strings.TrimSpace(id)
+		if !ok || id == "" {
+			return nil, fmt.Errorf("integration key %q must be id:base64key", entry)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("integration key %q must be 32 bytes, base64-encoded", id)
+		}
+		if _, dup := k.keys[id]; dup {
+			return nil, fmt.Errorf("integration key %q is listed twice", id)
+		}
+		if k.activeID == "" {
+			k.activeID = id
+		}
+		k.keys[id] = key
+	}
+	if k.activeID == "" {
+		return nil, nil
+	}
+	return k, nil
+}
+
+// gcmSeal encrypts plaintext with key, returning nonce||ciphertext.
+func gcmSeal(key, plaintext, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// gcmOpen decrypts nonce||ciphertext produced by gcmSeal.
+func gcmOpen(key, sealed, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], aad)
+}
+
+// sealedConfig is an integration config as stored: either plain JSON, or a
+// ciphertext with the wrapped data key and the ID of the master key.
+type sealedConfig struct {
+	Config     []byte
+	KeyID      sql.NullString
+	DataKey    []byte
+	Ciphertext []byte
+}
+
+// integrationConfigColumns are the integrations columns holding a config, in
+// the order scanned into a sealedConfig.
+const integrationConfigColumns = "config, config_key_id, config_dek, config_ciphertext"
+
+func (s *sealedConfig) scanArgs() []interface{} {
+	return []interface{}{&s.Config, &s.KeyID, &s.DataKey, &s.Ciphertext}
+}
+
+// values returns the column values to write, matching integrationConfigColumns.
+func (s sealedConfig) values() []interface{} {
+	var config, keyID interface{}
+	if s.Config != nil {
+		config = s.Config
+	}
+	if s.KeyID.Valid {
+		keyID = s.KeyID.String
+	}
+	return []interface{}{config, keyID, s.DataKey, s.Ciphertext}
+}
+
+// seal encrypts an integration's config under the active master key. The
+// integration ID is bound to the ciphertext so it cannot be moved to another
+// row. Without a keyring the config is stored as-is.
+func (k *keyring) seal(integrationID string, config []byte) (sealedConfig, error) {
+	if k == nil {
+		return sealedConfig{Config: config}, nil
+	}
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return sealedConfig{}, err
+	}
+	ciphertext, err := gcmSeal(dataKey, config, []byte(integrationID))
+	if err != nil {
+		return sealedConfig{}, err
+	}
+	wrapped, err := gcmSeal(k.keys[k.activeID], dataKey, []byte(k.activeID))
+	if err != nil {
+		return sealedConfig{}, err
+	}
+	return sealedConfig{
+		KeyID:      sql.NullString{String: k.activeID, Valid: true},
+		DataKey:    wrapped,
+		Ciphertext: ciphertext,
+	}, nil
+}
+
+// open returns the plain config JSON of a stored integration.
+func (k *keyring) open(integrationID string, s sealedConfig) (json.RawMessage, error) {
+	if !s.KeyID.Valid {
+		return json.RawMessage(s.Config), nil
+	}
+	if k == nil {
+		return nil, errors.New("integration config is encrypted but no INTEGRATION_KEYS are configured")
+	}
+	master, ok := k.keys[s.KeyID.String]
+	if !ok {
+		return nil, fmt.Errorf("integration config is encrypted with unknown key %q", s.KeyID.String)
+	}
+	dataKey, err := gcmOpen(master, s.DataKey, []byte(s.KeyID.String))
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+	config, err := gcmOpen(dataKey, s.Ciphertext, []byte(integrationID))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt integration config: %w", err)
+	}
+	return config, nil
+}
+
+// current reports whether a stored config is already sealed with the
+// active key (or, without a keyring, stored in plain).
+func (k *keyring) current(s sealedConfig) bool {
+	if k == nil {
+		return !s.KeyID.Valid
+	}
+	return s.KeyID.Valid && s.KeyID.String == k.activeID
+}
+
+// RotateIntegrationKeys re-encrypts every integration config under the
+// active key with a fresh data key, including configs still stored in
+// plain. With force unset, rows already on the active key are skipped. It
+// returns the number of rows rewritten.
+func (h *Handler) RotateIntegrationKeys(force bool) (int, error) {
+	if h.keys == nil {
+		return 0, errors.New("no INTEGRATION_KEYS configured")
+	}
+	rows, err := h.db.Query("SELECT id FROM integrations")
+	if err != nil {
+		return 0, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if rows.Scan(&id) == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	rotated := 0
+	for _, id := range ids {
+		done, err := h.rotateIntegration(id, force)
+		if err != nil {
+			return rotated, fmt.Errorf("integration %s: %w", id, err)
+		}
+		if done {
+			rotated++
+		}
+	}
+	return rotated, nil
+}
+
+// rotateIntegration re-seals one row, locking it so a concurrent save is not
+// overwritten with the config read before it.
+func (h *Handler) rotateIntegration(id string, force bool) (bool, error) {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var stored sealedConfig
+	err = tx.QueryRow("SELECT "+integrationConfigColumns+" FROM integrations WHERE id = ? FOR UPDATE", id).
+		Scan(stored.scanArgs()...)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !force && h.keys.current(stored) {
+		return false, nil
+	}
+	plain, err := h.keys.open(id, stored)
+	if err != nil {
+		return false, err
+	}
+	sealed, err := h.keys.seal(id, plain)
+	if err != nil {
+		return false, err
+	}
+	_, err = tx.Exec(
+		"UPDATE integrations SET config = ?, config_key_id = ?, config_dek = ?, config_ciphertext = ?, updated_at = updated_at WHERE id = ?",
+		append(sealed.values(), id)...,
+	)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// EncryptPlaintextIntegrations seals integration configs stored in plain,
+// e.g. rows written before encryption was enabled, or under a retired key.
+// It runs at startup and is a no-op without keys.
+func (h *Handler) EncryptPlaintextIntegrations() {
+	if h.keys == nil {
+		log.Println("⚠️ INTEGRATION_KEYS not set: integration credentials are stored unencrypted")
+		return
+	}
+	var stale int
+	h.db.QueryRow("SELECT COUNT(*) FROM integrations WHERE config_key_id IS NULL OR config_key_id != ?", h.keys.activeID).Scan(&stale)
+	if stale == 0 {
+		return
+	}
+	n, err := h.RotateIntegrationKeys(false)
+	if err != nil {
+		log.Printf("Failed to encrypt integration configs: %v", err)
+		return
+	}
+	log.Printf("🔐 Encrypted %d integration config(s) with key %s", n, h.keys.activeID)
+}
@@ -11,7 +11,53 @@ import (
 
 // ==================== Workflow Execution Engine ====================
 
-func (h *Handler) executeWorkflow(runID string, workflow Workflow, input json.RawMessage) {
+// runOptions carries the optional attributes recorded on a new run.
+type runOptions struct {
+	TriggerID    *string    // trigger that fired the run; nil for ad-hoc runs
+	ScheduledFor *time.Time // fire time of the schedule tick, if any
+	ReplayOf     *string    // webhook event replayed to start the run
+
+	// StartNodeID is the trigger node that fired the run, where execution
+	// starts; empty for runs not started by a node (see buildNodeMap).
+	StartNodeID string
+
+	// TriggerPayload is the raw event payload when the trigger node mapped
+	// it into a smaller input; it is stored beside the run's input.
+	TriggerPayload json.RawMessage
+
+	// Response, if set, receives the run's webhook_response (or a default
+	// once the run ends) for a caller holding its webhook request open.
+	Response chan webhookResponse
+}
+
+// startRun records a new run for the workflow and executes it in the
+// background.
+func (h *Handler) startRun(w Workflow, input json.RawMessage, opts runOptions) (string, error) {
+	if len(input) == 0 {
+		input = json.RawMessage(`{}`)
+	}
+	var triggerPayload interface{}
+	if len(opts.TriggerPayload) > 0 {
+		triggerPayload = []byte(opts.TriggerPayload)
+	}
+	runID := uuid.New().String()
+	_, err := h.db.Exec(
+		"INSERT INTO workflow_runs (id, workflow_id, trigger_id, scheduled_for, replay_of_event_id, status, input, trigger_payload) VALUES (?, ?, ?, ?, ?, 'running', ?, ?)",
+		runID, w.ID, opts.TriggerID, opts.ScheduledFor, opts.ReplayOf, input, triggerPayload,
+	)
+	if err != nil {
+		return "", err
+	}
+	if opts.Response != nil {
+		h.responses.register(runID, opts.Response)
+	}
+	go h.executeWorkflow(runID, w, input, opts.StartNodeID)
+	return runID, nil
+}
+
+// executeWorkflow runs the graph from startNodeID, or from the workflow's
+// default start node when that is empty or no longer in the workflow.
+func (h *Handler) executeWorkflow(runID string, workflow Workflow, input json.RawMessage, startNodeID string) {
 	var nodes []map[string]interface{}
 	json.Unmarshal(workflow.Nodes, &nodes)
 
@@ -19,8 +65,10 @@ func (h *Handler) executeWorkflow(runID string, workflow Workflow, input json.Ra
 	json.Unmarshal(workflow.Edges, &edges)
 
 	adj := buildAdjacencyMap(edges)
-	nodeMap, startNodeID := buildNodeMap(nodes)
-
+	nodeMap, defaultStart := buildNodeMap(nodes)
+	if _, ok := nodeMap[startNodeID]; !ok {
+		startNodeID = defaultStart
+	}
 	if startNodeID == "" && len(nodes) > 0 {
 		startNodeID, _ = nodes[0]["id"].(string)
 	}
@@ -52,7 +100,8 @@ func buildAdjacencyMap(edges []map[string]interface{}) map[string][]string {
 	return adj
 }
 
-// buildNodeMap creates node lookup map and finds start node.
+// buildNodeMap creates node lookup map and finds the default start node:
+// the start node, or else the first trigger node.
 func buildNodeMap(nodes []map[string]interface{}) (map[string]map[string]interface{}, string) {
 	nodeMap := map[string]map[string]interface{}{}
 	var startNodeID string
@@ -60,13 +109,22 @@ func buildNodeMap(nodes []map[string]interface{}) (map[string]map[string]interfa
 		nid, _ := node["id"].(string)
 		nodeMap[nid] = node
 		ntype, _ := node["type"].(string)
-		if ntype == "start" || ntype == "jira_webhook" {
+		if ntype == "start" || (startNodeID == "" && isTriggerNode(ntype)) {
 			startNodeID = nid
 		}
 	}
 	return nodeMap, startNodeID
 }
 
+// triggerNodeID returns the node a trigger belongs to, or "" for triggers
+// managed through the API.
+func triggerNodeID(t WorkflowTrigger) string {
+	if t.NodeID != nil {
+		return *t.NodeID
+	}
+	return ""
+}
+
 // executeWorkflowGraph walks the graph and executes nodes in BFS order.
 func (h *Handler) executeWorkflowGraph(runID, startNodeID string, nodeMap map[string]map[string]interface{}, adj map[string][]string, input json.RawMessage, envVars map[string]string) {
 	currentData := input
@@ -107,6 +165,7 @@ func (h *Handler) executeWorkflowGraph(runID, startNodeID string, nodeMap map[st
 	successMsg := fmt.Sprintf("Workflow completed successfully. %d nodes executed.", len(visited))
 	h.db.Exec("UPDATE workflow_runs SET status = 'success', output = ?, message = ?, finished_at = ? WHERE id = ?",
 		currentData, successMsg, now, runID)
+	h.responses.deliver(runID, webhookResponse{StatusCode: 200, Body: map[string]interface{}{"status": "completed", "run_id": runID}})
 	log.Printf("🎉 Workflow run %s completed successfully", runID)
 }
 
@@ -131,31 +190,60 @@ func (h *Handler) executeWorkflowNode(runID, nodeID, nodeType string, data map[s
 		failMsg := fmt.Sprintf("Node '%s' (%s) failed: %s", nodeName, nodeType, errMsg)
 		h.db.Exec("UPDATE workflow_runs SET status = 'failed', output = ?, message = ?, finished_at = ? WHERE id = ?",
 			output, failMsg, now, runID)
+		// The caller only learns that the run failed; the detail may name
+		// internal systems and stays in the run's message.
+		h.responses.deliver(runID, webhookResponse{StatusCode: 500, Body: map[string]interface{}{"error": "workflow failed", "run_id": runID}})
 		log.Printf("❌ Node %s (%s) failed: %s", nodeID, nodeType, errMsg)
 		return false
 	}
 
 	h.db.Exec("UPDATE workflow_logs SET status = 'completed', output = ? WHERE id = ?", output, logID)
-	*currentData = output
+	if nodeType == "webhook_response" {
+		// The response goes to the waiting caller; later nodes keep this node's input.
+		var resp webhookResponse
+		json.Unmarshal(output, &resp)
+		h.responses.deliver(runID, resp)
+	} else {
+		*currentData = output
+	}
 	log.Printf("✅ Node %s (%s) completed", nodeID, nodeType)
 	return true
 }
 
 // executeNode dispatches to the correct executor based on node type.
 func (h *Handler) executeNode(nodeType string, data map[string]interface{}, input json.RawMessage) (json.RawMessage, string) {
-	switch nodeType {
-	case "start", "jira_webhook":
+	if isTriggerNode(nodeType) {
 		return input, ""
+	}
+	switch nodeType {
 	case "http_request":
 		return h.executeHTTPRequest(data, input)
 	case "jira_create_issue":
 		return h.executeJiraCreateIssue(data, input)
+	case "jira_update_issue":
+		return h.executeJiraUpdateIssue(data, input)
+	case "jira_transition_issue":
+		return h.executeJiraTransitionIssue(data, input)
+	case "jira_add_comment":
+		return h.executeJiraAddComment(data, input)
+	case "jira_assign_issue":
+		return h.executeJiraAssignIssue(data, input)
+	case "jira_link_issues":
+		return h.executeJiraLinkIssues(data, input)
+	case "jira_search":
+		return h.executeJiraSearch(data, input)
 	case "slack_message":
 		return h.executeSlackMessage(data, input)
+	case "slack_upload_file":
+		return h.executeSlackUploadFile(data, input)
+	case "slack_lookup_user":
+		return h.executeSlackLookupUser(data, input)
 	case "datadog_event":
 		return h.executeDatadogEvent(data, input)
 	case "delay":
 		return executeDelay(data, input)
+	case "webhook_response":
+		return executeWebhookResponse(data, input)
 	case "condition", "transform", "end":
 		return input, ""
 	default:
@@ -3,6 +3,7 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -15,6 +16,9 @@ import (
 // ==================== Custom Node Executor ====================
 
 // executeCustomNode uses the node_schemas execute_config to make a dynamic HTTP call.
+// An execute_config "connection" names an integration type (e.g. "oauth2")
+// whose connection authenticates the call with a bearer token: the node's
+// connection_id, or the type's default.
 func (h *Handler) executeCustomNode(nodeType string, data map[string]interface{}, input json.RawMessage) (json.RawMessage, string) {
 	schema, err := h.fetchNodeSchemaByType(nodeType)
 	if err != nil || len(schema.ExecuteConfig) == 0 || string(schema.ExecuteConfig) == "null" {
@@ -22,10 +26,11 @@ func (h *Handler) executeCustomNode(nodeType string, data map[string]interface{}
 	}
 
 	var cfg struct {
-		URL     string            `json:"url"`
-		Method  string            `json:"method"`
-		Headers map[string]string `json:"headers"`
-		Body    string            `json:"body"`
+		URL        string            `json:"url"`
+		Method     string            `json:"method"`
+		Headers    map[string]string `json:"headers"`
+		Body       string            `json:"body"`
+		Connection string            `json:"connection"`
 	}
 	if err := json.Unmarshal(schema.ExecuteConfig, &cfg); err != nil {
 		return nil, fmt.Sprintf("executeCustomNode: invalid execute_config: %v", err)
@@ -72,6 +77,16 @@ func (h *Handler) executeCustomNode(nodeType string, data map[string]interface{}
 	for k, v := range cfg.Headers {
 		req.Header.Set(k, tpl(v))
 	}
+	if cfg.Connection != "" {
+		connectionID := nodeConnectionID(data, inputMap)
+		connConfig, err := h.loadConnectionConfig(cfg.Connection, connectionID)
+		if err != nil {
+			return nil, "executeCustomNode: " + connectionError(cfg.Connection, connectionID, err)
+		}
+		if token := connectionToken(connConfig, "token"); token != "" {
+			req.Header.Set(AuthorizationHeader, "Bearer "+token)
+		}
+	}
 	if req.Header.Get("Content-Type") == "" && body != "" {
 		req.Header.Set("Content-Type", "application/json")
 	}
@@ -188,6 +203,14 @@ func (h *Handler) executeHTTPRequest(data map[string]interface{}, input json.Raw
 	req.Header.Set(ContentTypeHeader, ContentTypeJSON)
 	setHTTPRequestHeaders(req, data)
 	setHTTPRequestAuth(req, data)
+	if data["auth_type"] == "oauth2" {
+		connectionID := nodeConnectionID(data, inputMap)
+		token, err := h.oauthAccessToken("oauth2", connectionID)
+		if err != nil {
+			return nil, connectionError("OAuth2", connectionID, err)
+		}
+		req.Header.Set(AuthorizationHeader, "Bearer "+token)
+	}
 
 	client := &http.Client{Timeout: parseHTTPTimeout(data)}
 	resp, err := client.Do(req)
@@ -270,47 +293,25 @@ func parseHTTPTimeout(data map[string]interface{}) time.Duration {
 }
 
 func (h *Handler) executeJiraCreateIssue(data map[string]interface{}, input json.RawMessage) (json.RawMessage, string) {
-	jiraConfig, err := h.loadIntegrationConfig("jira")
-	if err != nil {
-		return nil, "Jira integration not configured. Go to Settings → Integrations to set it up."
-	}
-
-	domain, _ := jiraConfig["domain"].(string)
-	email, _ := jiraConfig["email"].(string)
-	apiToken, _ := jiraConfig["api_token"].(string)
-
-	if domain == "" || email == "" || apiToken == "" {
-		return nil, "Jira integration config incomplete: need domain, email, api_token"
-	}
-
 	var inputMap map[string]interface{}
 	json.Unmarshal(input, &inputMap)
 
-	jiraPayload, projectKey, err := buildJiraIssuePayload(data, inputMap)
+	jc, err := h.jiraClientForConnection(nodeConnectionID(data, inputMap))
 	if err != nil {
 		return nil, err.Error()
 	}
 
-	payloadBytes, _ := json.Marshal(jiraPayload)
-	url := fmt.Sprintf("https://%s/rest/api/3/issue", domain)
-	req, err := http.NewRequest("POST", url, bytes.NewReader(payloadBytes))
+	jiraPayload, projectKey, err := buildJiraIssuePayload(data, inputMap)
 	if err != nil {
-		return nil, fmt.Sprintf("Failed to create Jira request: %v", err)
+		return nil, err.Error()
 	}
-	req.SetBasicAuth(email, apiToken)
-	req.Header.Set(ContentTypeHeader, ContentTypeJSON)
-	req.Header.Set("Accept", ContentTypeJSON)
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	respBody, err := jc.do("POST", "/rest/api/3/issue", jiraPayload)
 	if err != nil {
-		return nil, fmt.Sprintf("Jira API call failed: %v", err)
-	}
-	defer resp.Body.Close()
-	respBody, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode >= 400 {
-		return json.RawMessage(respBody), fmt.Sprintf("Jira API error %d: %s", resp.StatusCode, string(respBody))
+		if _, ok := err.(*jiraAPIError); ok {
+			return json.RawMessage(respBody), err.Error()
+		}
+		return nil, err.Error()
 	}
 
 	var jiraResp map[string]interface{}
@@ -329,8 +330,8 @@ func (h *Handler) executeJiraCreateIssue(data map[string]interface{}, input json
 	for k, v := range jiraResp {
 		enriched[k] = v
 	}
-	if key, ok := jiraResp["key"].(string); ok && domain != "" {
-		browseURL := fmt.Sprintf("https://%s/browse/%s", domain, key)
+	if key, ok := jiraResp["key"].(string); ok {
+		browseURL := jc.browseURL(key)
 		enriched["self"] = browseURL
 		enriched["browse_url"] = browseURL
 		enriched["link"] = browseURL
@@ -430,77 +431,140 @@ func convertTextToADF(text string) map[string]interface{} {
 	}
 	var contentBlocks []map[string]interface{}
 	for _, line := range strings.Split(text, "\n") {
-		contentBlocks = append(contentBlocks, map[string]interface{}{
-			"type": "paragraph",
-			"content": []map[string]interface{}{
-				{"type": "text", "text": line},
-			},
-		})
+		// ADF rejects empty text nodes, so blank lines are empty paragraphs.
+		content := []map[string]interface{}{}
+		if line != "" {
+			content = append(content, map[string]interface{}{"type": "text", "text": line})
+		}
+		contentBlocks = append(contentBlocks, map[string]interface{}{"type": "paragraph", "content": content})
 	}
 	return map[string]interface{}{"type": "doc", "version": 1, "content": contentBlocks}
 }
 
+// executeSlackMessage posts, updates or deletes a message, or posts an
+// ephemeral one, depending on the node's action. The message is text, Block
+// Kit blocks, or both (the text is then the notification fallback).
 func (h *Handler) executeSlackMessage(data map[string]interface{}, input json.RawMessage) (json.RawMessage, string) {
-	slackConfig, err := h.loadIntegrationConfig("slack")
-	if err != nil {
-		return nil, "Slack integration not configured. Go to Settings → Integrations to set it up."
-	}
+	var inputMap map[string]interface{}
+	json.Unmarshal(input, &inputMap)
 
-	botToken, _ := slackConfig["bot_token"].(string)
-	if botToken == "" {
-		return nil, "Slack integration config incomplete: need bot_token"
+	// reply_in_thread answers the Slack event that triggered the run: its
+	// connection, channel and thread are used unless set explicitly.
+	trigger, _ := inputMap["slack"].(map[string]interface{})
+	replyInThread := data["reply_in_thread"] == true || data["reply_in_thread"] == "true"
+
+	connectionID := nodeConnectionID(data, inputMap)
+	if connectionID == "" && replyInThread && trigger != nil {
+		connectionID, _ = trigger["connection_id"].(string)
+	}
+	sc, err := h.slackClientForConnection(connectionID)
+	if err != nil {
+		return nil, err.Error()
 	}
 
 	channel, _ := data["channel"].(string)
-	messageText, _ := data["message"].(string)
+	channel = strings.TrimSpace(templateReplace(channel, inputMap))
+	threadTs, _ := data["thread_ts"].(string)
+	threadTs = templateReplace(threadTs, inputMap)
+
+	if replyInThread && trigger != nil {
+		if channel == "" {
+			channel, _ = trigger["channel"].(string)
+		}
+		if threadTs == "" {
+			threadTs, _ = trigger["thread_ts"].(string)
+		}
+	}
 	if channel == "" {
 		return nil, "Slack Message node: channel is required"
 	}
+
+	action, _ := data["action"].(string)
+	if action == "" {
+		action = "post"
+	}
+	// chat.postMessage and chat.postEphemeral take channel names; updates
+	// and deletes need the ID, and an email means a DM with that user.
+	if action == "update" || action == "delete" || strings.Contains(channel, "@") {
+		if channel, err = sc.resolveChannel(channel); err != nil {
+			return nil, "Slack Message node: " + err.Error()
+		}
+	}
+
+	messageTs, _ := data["message_ts"].(string)
+	messageTs = strings.TrimSpace(templateReplace(messageTs, inputMap))
+	if (action == "update" || action == "delete") && messageTs == "" {
+		return nil, fmt.Sprintf("Slack Message node: message_ts is required to %s a message", action)
+	}
+	if action == "delete" {
+		respBody, err := sc.call("chat.delete", map[string]interface{}{"channel": channel, "ts": messageTs}, nil)
+		if err != nil {
+			return slackErrorOutput(respBody), err.Error()
+		}
+		log.Printf("💬 Slack message %s deleted from %s", messageTs, channel)
+		return json.RawMessage(respBody), ""
+	}
+
+	blocks, err := slackBlocks(data, inputMap)
+	if err != nil {
+		return nil, "Slack Message node: " + err.Error()
+	}
+	messageText, _ := data["message"].(string)
 	if messageText == "" {
 		messageText = "Workflow notification"
 	}
-
-	var inputMap map[string]interface{}
-	json.Unmarshal(input, &inputMap)
 	messageText = templateReplace(messageText, inputMap)
 
 	slackPayload := map[string]interface{}{"channel": channel, "text": messageText}
-	if username, _ := data["username"].(string); username != "" {
-		slackPayload["username"] = username
-	}
-	if iconEmoji, _ := data["icon_emoji"].(string); iconEmoji != "" {
-		slackPayload["icon_emoji"] = iconEmoji
+	if blocks != nil {
+		slackPayload["blocks"] = blocks
+	}
+	method := "chat.postMessage"
+	switch action {
+	case "update":
+		method = "chat.update"
+		slackPayload["ts"] = messageTs
+	case "ephemeral":
+		method = "chat.postEphemeral"
+		user, _ := data["user"].(string)
+		user = strings.TrimSpace(templateReplace(user, inputMap))
+		if user == "" {
+			return nil, "Slack Message node: user is required for an ephemeral message"
+		}
+		if slackPayload["user"], err = sc.resolveUser(user); err != nil {
+			return nil, "Slack Message node: " + err.Error()
+		}
 	}
-	if threadTs, _ := data["thread_ts"].(string); threadTs != "" {
-		if threadTs = templateReplace(threadTs, inputMap); threadTs != "" {
+	if action != "update" {
+		if username, _ := data["username"].(string); username != "" {
+			slackPayload["username"] = username
+		}
+		if iconEmoji, _ := data["icon_emoji"].(string); iconEmoji != "" {
+			slackPayload["icon_emoji"] = iconEmoji
+		}
+		if threadTs != "" {
 			slackPayload["thread_ts"] = threadTs
 		}
 	}
 
-	payloadBytes, _ := json.Marshal(slackPayload)
-	req, err := http.NewRequest("POST", "https://slack.com/api/chat.postMessage", bytes.NewReader(payloadBytes))
+	respBody, err := sc.call(method, slackPayload, nil)
 	if err != nil {
-		return nil, fmt.Sprintf("Failed to create Slack request: %v", err)
-	}
-	req.Header.Set(ContentTypeHeader, ContentTypeJSON)
-	req.Header.Set(AuthorizationHeader, "Bearer "+botToken)
-
-	client := &http.Client{Timeout: 15 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Sprintf("Slack API call failed: %v", err)
-	}
-	defer resp.Body.Close()
-	respBody, _ := io.ReadAll(resp.Body)
-
-	var slackResp map[string]interface{}
-	json.Unmarshal(respBody, &slackResp)
-	if ok, _ := slackResp["ok"].(bool); !ok {
-		errStr, _ := slackResp["error"].(string)
-		return json.RawMessage(respBody), fmt.Sprintf("Slack API error: %s", errStr)
+		// A slash command can be answered through its response_url even
+		// where the bot is not a channel member.
+		var apiErr *slackAPIError
+		responseURL, _ := trigger["response_url"].(string)
+		if errors.As(err, &apiErr) && action == "post" && replyInThread && responseURL != "" &&
+			(apiErr.Code == "not_in_channel" || apiErr.Code == "channel_not_found") {
+			if err := postSlackResponseURL(responseURL, messageText); err != nil {
+				return json.RawMessage(respBody), fmt.Sprintf("Slack response_url error: %v", err)
+			}
+			log.Printf("💬 Slack reply sent via response_url")
+			return json.RawMessage(`{"ok":true,"via":"response_url"}`), ""
+		}
+		return slackErrorOutput(respBody), err.Error()
 	}
 
-	log.Printf("💬 Slack message sent to #%s", channel)
+	log.Printf("💬 Slack message sent to %s (%s)", channel, method)
 	return json.RawMessage(respBody), ""
 }
 
@@ -0,0 +1,550 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ==================== Filter Expressions ====================
+//
+// A small boolean expression language evaluated against an event payload,
+// set as filter_expression on any trigger node:
+//
+//	issue.fields.priority.name in ["High", "Highest"] && changelog.items[0].field == "status"
+//	body.amount >= 100 || !(headers.x-env == "staging")
+//
+// Paths use dots and [n] indexes and resolve to null when missing. Literals
+// are strings (single or double quoted), numbers, true, false, null and
+// [arrays]. Operators: ==, !=, <, >, <=, >=, in, with &&, ||, ! and
+// parentheses. A number and a numeric string compare as numbers; "in" tests
+// array membership, substrings of a string or keys of an object. A bare
+// path is true when it is present and not false, 0 or empty.
+
+type exprNode interface {
+	eval(payload map[string]interface{}) (interface{}, error)
+	String() string
+}
+
+type exprLiteral struct{ value interface{} }
+type exprPath struct{ path string }
+type exprArray struct{ items []exprNode }
+type exprNot struct{ inner exprNode }
+
+type exprLogic struct {
+	op          string // "&&" or "||"
+	left, right exprNode
+}
+
+type exprCompare struct {
+	op          string
+	left, right exprNode
+}
+
+func (n exprLiteral) eval(map[string]interface{}) (interface{}, error) { return n.value, nil }
+
+func (n exprLiteral) String() string {
+	b, _ := json.Marshal(n.value)
+	return string(b)
+}
+
+func (n exprPath) eval(payload map[string]interface{}) (interface{}, error) {
+	v, _ := lookupPath(payload, n.path)
+	return v, nil
+}
+
+func (n exprPath) String() string { return n.path }
+
+func (n exprArray) eval(payload map[string]interface{}) (interface{}, error) {
+	out := make([]interface{}, 0, len(n.items))
+	for _, item := range n.items {
+		v, err := item.eval(payload)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func (n exprArray) String() string {
+	parts := make([]string, len(n.items))
+	for i, item := range n.items {
+		parts[i] = item.String()
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func (n exprNot) eval(payload map[string]interface{}) (interface{}, error) {
+	v, err := n.inner.eval(payload)
+	if err != nil {
+		return nil, err
+	}
+	return !exprTruthy(v), nil
+}
+
+func (n exprNot) String() string { return "!" + n.inner.String() }
+
+func (n exprLogic) eval(payload map[string]interface{}) (interface{}, error) {
+	l, err := n.left.eval(payload)
+	if err != nil {
+		return nil, err
+	}
+	if exprTruthy(l) == (n.op == "||") {
+		return exprTruthy(l), nil
+	}
+	r, err := n.right.eval(payload)
+	if err != nil {
+		return nil, err
+	}
+	return exprTruthy(r), nil
+}
+
+func (n exprLogic) String() string {
+	return "(" + n.left.String() + " " + n.op + " " + n.right.String() + ")"
+}
+
+func (n exprCompare) eval(payload map[string]interface{}) (interface{}, error) {
+	l, err := n.left.eval(payload)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.right.eval(payload)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return exprEqual(l, r), nil
+	case "!=":
+		return !exprEqual(l, r), nil
+	case "in":
+		return exprIn(l, r)
+	}
+	if l == nil || r == nil {
+		return false, nil
+	}
+	cmp, err := exprOrder(l, r)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "<":
+		return cmp < 0, nil
+	case ">":
+		return cmp > 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+func (n exprCompare) String() string {
+	return n.left.String() + " " + n.op + " " + n.right.String()
+}
+
+// exprTruthy reports whether a value counts as true.
+func exprTruthy(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return x
+	case float64:
+		return x != 0
+	case string:
+		return x != ""
+	case []interface{}:
+		return len(x) > 0
+	case map[string]interface{}:
+		return len(x) > 0
+	}
+	return true
+}
+
+// exprNumber returns v as a number when it is one or a numeric string.
+func exprNumber(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func exprEqual(a, b interface{}) bool {
+	_, aNum := a.(float64)
+	_, bNum := b.(float64)
+	if aNum || bNum {
+		x, ok1 := exprNumber(a)
+		y, ok2 := exprNumber(b)
+		if ok1 && ok2 {
+			return x == y
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// exprOrder compares two numbers or two strings.
+func exprOrder(a, b interface{}) (int, error) {
+	_, aNum := a.(float64)
+	_, bNum := b.(float64)
+	if aNum || bNum {
+		x, ok1 := exprNumber(a)
+		y, ok2 := exprNumber(b)
+		if !ok1 || !ok2 {
+			return 0, fmt.Errorf("cannot compare %s and %s", exprDescribe(a), exprDescribe(b))
+		}
+		switch {
+		case x < y:
+			return -1, nil
+		case x > y:
+			return 1, nil
+		}
+		return 0, nil
+	}
+	x, ok1 := a.(string)
+	y, ok2 := b.(string)
+	if !ok1 || !ok2 {
+		return 0, fmt.Errorf("cannot compare %s and %s", exprDescribe(a), exprDescribe(b))
+	}
+	return strings.Compare(x, y), nil
+}
+
+func exprIn(needle, haystack interface{}) (interface{}, error) {
+	switch h := haystack.(type) {
+	case nil:
+		return false, nil
+	case []interface{}:
+		for _, item := range h {
+			if exprEqual(needle, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case string:
+		s, ok := needle.(string)
+		return ok && strings.Contains(h, s), nil
+	case map[string]interface{}:
+		s, ok := needle.(string)
+		if !ok {
+			return false, nil
+		}
+		_, found := h[s]
+		return found, nil
+	}
+	return nil, fmt.Errorf("right side of 'in' must be an array, string or object, got %s", exprDescribe(haystack))
+}
+
+// exprDescribe renders a value for error messages.
+func exprDescribe(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// ---- parser ----
+
+type exprToken struct {
+	kind string // "path", "string", "number", "op", "(", ")", "[", "]", ","
+	text string
+}
+
+// isExprPathRune reports whether r may continue a path. Hyphens are allowed
+// so header names such as headers.x-github-event can be written directly.
+func isExprPathRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.' || r == '$'
+}
+
+func tokenizeExpr(src string) ([]exprToken, error) {
+	var tokens []exprToken
+	rs := []rune(src)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == '[' || r == ']' || r == ',':
+			tokens = append(tokens, exprToken{kind: string(r), text: string(r)})
+			i++
+		case r == '"' || r == '\'':
+			j := i + 1
+			var sb strings.Builder
+			for j < len(rs) && rs[j] != r {
+				if rs[j] == '\\' && j+1 < len(rs) {
+					j++
+				}
+				sb.WriteRune(rs[j])
+				j++
+			}
+			if j >= len(rs) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, exprToken{kind: "string", text: sb.String()})
+			i = j + 1
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(rs) && unicode.IsDigit(rs[i+1])):
+			j := i + 1
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, exprToken{kind: "number", text: string(rs[i:j])})
+			i = j
+		case strings.ContainsRune("=!<>&|", r):
+			two := ""
+			if i+1 < len(rs) {
+				two = string(rs[i : i+2])
+			}
+			switch {
+			case two == "==" || two == "!=" || two == "<=" || two == ">=" || two == "&&" || two == "||":
+				tokens = append(tokens, exprToken{kind: "op", text: two})
+				i += 2
+			case r == '<' || r == '>' || r == '!':
+				tokens = append(tokens, exprToken{kind: "op", text: string(r)})
+				i++
+			default:
+				return nil, fmt.Errorf("unexpected %q (use ==, && or ||)", r)
+			}
+		case unicode.IsLetter(r) || r == '_' || r == '$':
+			j := i
+			for j < len(rs) {
+				if isExprPathRune(rs[j]) {
+					j++
+					continue
+				}
+				// An index directly after a path segment: items[0]
+				if rs[j] == '[' {
+					k := j + 1
+					for k < len(rs) && unicode.IsDigit(rs[k]) {
+						k++
+					}
+					if k > j+1 && k < len(rs) && rs[k] == ']' {
+						j = k + 1
+						continue
+					}
+				}
+				break
+			}
+			tokens = append(tokens, exprToken{kind: "path", text: string(rs[i:j])})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected %q", r)
+		}
+	}
+	return tokens, nil
+}
+
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+}
+
+// parseExpr compiles a filter expression into an evaluable tree.
+func parseExpr(src string) (exprNode, error) {
+	tokens, err := tokenizeExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	p := &exprParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	return node, nil
+}
+
+func (p *exprParser) peek() (exprToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return exprToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// accept consumes the next token if it is the given operator, keyword or
+// punctuation.
+func (p *exprParser) accept(text string) bool {
+	t, ok := p.peek()
+	if ok && t.kind != "string" && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = exprLogic{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = exprLogic{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.accept("!") {
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return exprNot{inner: inner}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">", "in"} {
+		if p.accept(op) {
+			right, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			return exprCompare{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseOperand() (exprNode, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	p.pos++
+	switch t.kind {
+	case "(":
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		return node, nil
+	case "[":
+		return p.parseArray()
+	case "string":
+		return exprLiteral{value: t.text}, nil
+	case "number":
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", t.text)
+		}
+		return exprLiteral{value: f}, nil
+	case "path":
+		switch t.text {
+		case "true":
+			return exprLiteral{value: true}, nil
+		case "false":
+			return exprLiteral{value: false}, nil
+		case "null":
+			return exprLiteral{value: nil}, nil
+		case "in":
+			return nil, fmt.Errorf("unexpected 'in'")
+		}
+		return exprPath{path: t.text}, nil
+	}
+	return nil, fmt.Errorf("unexpected %q", t.text)
+}
+
+func (p *exprParser) parseArray() (exprNode, error) {
+	var items []exprNode
+	if p.accept("]") {
+		return exprArray{}, nil
+	}
+	for {
+		item, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if p.accept("]") {
+			return exprArray{items: items}, nil
+		}
+		if !p.accept(",") {
+			return nil, fmt.Errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+// explainFalse names the part of an expression that made it false, following
+// && chains down to the failing comparison and showing the actual value.
+func explainFalse(node exprNode, payload map[string]interface{}) string {
+	switch n := node.(type) {
+	case exprLogic:
+		if n.op == "&&" {
+			if v, _ := n.left.eval(payload); !exprTruthy(v) {
+				return explainFalse(n.left, payload)
+			}
+			return explainFalse(n.right, payload)
+		}
+	case exprCompare:
+		if path, ok := n.left.(exprPath); ok {
+			actual, _ := path.eval(payload)
+			return fmt.Sprintf("%s is false (%s is %s)", n.String(), path.path, exprDescribe(actual))
+		}
+	case exprPath:
+		actual, _ := n.eval(payload)
+		return fmt.Sprintf("%s is %s", n.path, exprDescribe(actual))
+	}
+	return node.String() + " is false"
+}
+
+// evalFilterExpression evaluates a trigger node's filter_expression against
+// an event payload. It returns whether the event passes and, if not, why.
+func evalFilterExpression(src string, payload map[string]interface{}) (bool, string) {
+	node, err := parseExpr(src)
+	if err != nil {
+		return false, fmt.Sprintf("invalid filter expression: %v", err)
+	}
+	v, err := node.eval(payload)
+	if err != nil {
+		return false, fmt.Sprintf("filter expression error: %v", err)
+	}
+	if !exprTruthy(v) {
+		return false, "filter expression: " + explainFalse(node, payload)
+	}
+	return true, ""
+}
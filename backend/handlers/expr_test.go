@@ -0,0 +1,175 @@
+package handlers
+
+import "testing"
+
+// exprPayload is the event the filter tests run against, shaped like
+// a decoded JSON payload (numbers are float64).
+var exprPayload = map[string]interface{}{
+	"issue": map[string]interface{}{
+		"key": "WOP-1",
+		"fields": map[string]interface{}{
+			"priority": map[string]interface{}{"name": "High"},
+			"labels":   []interface{}{"backend", "urgent"},
+			"points":   5.0,
+		},
+	},
+	"changelog": map[string]interface{}{
+		"items": []interface{}{map[string]interface{}{"field": "status", "toString": "Done"}},
+	},
+	"body": map[string]interface{}{
+		"amount": 120.0,
+		"count":  "7",
+		"empty":  "",
+		"zero":   0.0,
+		"flag":   false,
+		"tags":   map[string]interface{}{"env": "prod"},
+	},
+	"headers": map[string]interface{}{"x-env": "staging"},
+}
+
+func TestEvalFilterExpression(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want bool
+	}{
+		// comparison operators
+		{"equal string", `issue.fields.priority.name == "High"`, true},
+		{"equal single quotes", `issue.key == 'WOP-1'`, true},
+		{"not equal", `issue.fields.priority.name != "Low"`, true},
+		{"less than", `body.amount < 200`, true},
+		{"greater than", `body.amount > 200`, false},
+		{"less or equal", `body.amount <= 120`, true},
+		{"greater or equal", `body.amount >= 121`, false},
+		{"negative number", `body.amount > -1`, true},
+		{"string ordering", `issue.key < "WOP-2"`, true},
+		{"numeric string equals number", `body.count == 7`, true},
+		{"numeric string ordered as number", `body.count > 10`, false},
+		{"array index", `changelog.items[0].field == "status"`, true},
+		{"hyphenated path", `headers.x-env == "staging"`, true},
+		{"booleans", `body.flag == false`, true},
+
+		// logic and precedence
+		{"and", `body.amount > 100 && issue.key == "WOP-1"`, true},
+		{"and short", `body.amount > 500 && issue.key == "WOP-1"`, false},
+		{"or", `body.amount > 500 || issue.key == "WOP-1"`, true},
+		{"and binds tighter than or", `true || false && false`, true},
+		{"and binds tighter than or, left", `false && false || true`, true},
+		{"parentheses override precedence", `(true || false) && false`, false},
+		{"not", `!(headers.x-env == "staging")`, false},
+		{"not binds tighter than and", `!false && true`, true},
+		{"double not", `!!body.amount`, true},
+
+		// in
+		{"in array literal", `issue.fields.priority.name in ["High", "Highest"]`, true},
+		{"not in array literal", `issue.fields.priority.name in ["Low"]`, false},
+		{"in empty array", `issue.key in []`, false},
+		{"in array path", `"urgent" in issue.fields.labels`, true},
+		{"number in array", `5 in [1, 5]`, true},
+		{"numeric string in array", `body.count in [7, 8]`, true},
+		{"in string is substring", `"WOP" in issue.key`, true},
+		{"not substring", `"ABC" in issue.key`, false},
+		{"number in string", `1 in issue.key`, false},
+		{"in object is key", `"env" in body.tags`, true},
+		{"missing key in object", `"region" in body.tags`, false},
+		{"in missing path", `"x" in body.nothing`, false},
+
+		// truthiness and missing paths
+		{"bare path present", `issue.key`, true},
+		{"bare path empty string", `body.empty`, false},
+		{"bare path zero", `body.zero`, false},
+		{"bare path false", `body.flag`, false},
+		{"bare path object", `body.tags`, true},
+		{"missing path is falsy", `body.missing.deep`, false},
+		{"missing path equals null", `body.missing == null`, true},
+		{"missing path not equal to string", `body.missing != "x"`, true},
+		{"missing path ordering is false", `body.missing > 1`, false},
+		{"missing index", `changelog.items[5].field == "status"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := evalFilterExpression(tt.expr, exprPayload)
+			if got != tt.want {
+				t.Errorf("evalFilterExpression(%q) = %v (%s), want %v", tt.expr, got, reason, tt.want)
+			}
+			if got && reason != "" {
+				t.Errorf("evalFilterExpression(%q) passed with reason %q", tt.expr, reason)
+			}
+		})
+	}
+}
+
+func TestEvalFilterExpressionReasons(t *testing.T) {
+	tests := []struct {
+		name   string
+		expr   string
+		reason string
+	}{
+		{"failing comparison shows actual value", `issue.fields.priority.name == "Low"`,
+			`filter expression: issue.fields.priority.name == "Low" is false (issue.fields.priority.name is "High")`},
+		{"follows and chain", `body.amount > 100 && issue.fields.priority.name in ["Low"]`,
+			`filter expression: issue.fields.priority.name in ["Low"] is false (issue.fields.priority.name is "High")`},
+		{"missing path", `body.missing`, `filter expression: body.missing is null`},
+		{"missing path in comparison", `body.missing.deep == 1`,
+			`filter expression: body.missing.deep == 1 is false (body.missing.deep is null)`},
+		{"other nodes", `!body.amount`, `filter expression: !body.amount is false`},
+
+		{"compare string with number", `issue.key > 5`,
+			`filter expression error: cannot compare "WOP-1" and 5`},
+		{"compare object", `body.tags < "a"`,
+			`filter expression error: cannot compare an object and "a"`},
+		{"in number", `"a" in body.amount`,
+			`filter expression error: right side of 'in' must be an array, string or object, got 120`},
+		{"in bool", `"a" in body.flag`,
+			`filter expression error: right side of 'in' must be an array, string or object, got false`},
+
+		{"empty", ``, `invalid filter expression: empty expression`},
+		{"blank", `   `, `invalid filter expression: empty expression`},
+		{"single equals", `issue.key = "WOP-1"`, `invalid filter expression: unexpected '=' (use ==, && or ||)`},
+		{"single ampersand", `true & false`, `invalid filter expression: unexpected '&' (use ==, && or ||)`},
+		{"unterminated string", `issue.key == "WOP`, `invalid filter expression: unterminated string`},
+		{"missing paren", `(true || false`, `invalid filter expression: missing ')'`},
+		{"trailing operator", `issue.key ==`, `invalid filter expression: unexpected end of expression`},
+		{"trailing token", `true false`, `invalid filter expression: unexpected "false"`},
+		{"bad array", `issue.key in ["a" "b"]`, `invalid filter expression: expected ',' or ']' in array`},
+		{"in without left side", `in ["a"]`, `invalid filter expression: unexpected 'in'`},
+		{"invalid number", `body.amount > 1.2.3`, `invalid filter expression: invalid number "1.2.3"`},
+		{"unexpected character", `issue.key == #1`, `invalid filter expression: unexpected '#'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := evalFilterExpression(tt.expr, exprPayload)
+			if got {
+				t.Fatalf("evalFilterExpression(%q) = true, want false", tt.expr)
+			}
+			if reason != tt.reason {
+				t.Errorf("evalFilterExpression(%q) reason\n got: %s\nwant: %s", tt.expr, reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestParseExprString(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{`a || b && c`, `(a || (b && c))`},
+		{`a && b || c`, `((a && b) || c)`},
+		{`a && (b || c)`, `(a && (b || c))`},
+		{`!a && b`, `(!a && b)`},
+		{`a == 1 || b in ["x", 2]`, `(a == 1 || b in ["x", 2])`},
+		{`items[0].id != null`, `items[0].id != null`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			node, err := parseExpr(tt.expr)
+			if err != nil {
+				t.Fatalf("parseExpr(%q): %v", tt.expr, err)
+			}
+			if got := node.String(); got != tt.want {
+				t.Errorf("parseExpr(%q) = %s, want %s", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
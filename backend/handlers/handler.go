@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"database/sql"
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -19,12 +20,29 @@ const (
 
 // Handler holds shared dependencies for all HTTP handler methods.
 type Handler struct {
-	db *sql.DB
+	db        *sql.DB
+	scheduler *cronScheduler
+	backfills *backfillRegistry
+	responses *responseRegistry
+	keys      *keyring
 }
 
-// New creates a new Handler with the given database connection.
+// New creates a new Handler with the given database connection. It exits if
+// the integration encryption keys are set but invalid. Without any keys
+// (INTEGRATION_KEYS unset) credentials are stored unencrypted, with a warning
+// at startup.
 func New(db *sql.DB) *Handler {
-	return &Handler{db: db}
+	keys, err := loadKeyring()
+	if err != nil {
+		log.Fatalf("Invalid integration encryption keys: %v", err)
+	}
+	return &Handler{
+		db:        db,
+		scheduler: newCronScheduler(),
+		backfills: newBackfillRegistry(),
+		responses: newResponseRegistry(),
+		keys:      keys,
+	}
 }
 
 // RegisterRoutes attaches all API routes to the given gin Engine.
@@ -49,18 +67,66 @@ func (h *Handler) RegisterRoutes(r *gin.Engine) {
 		api.GET("/integrations/:type", h.getIntegration)
 		api.PUT("/integrations/:type", h.upsertIntegration)
 		api.DELETE("/integrations/:type", h.deleteIntegration)
+		api.POST("/integrations/:type/reveal", h.revealIntegrationSecrets)
+		api.POST("/integrations/:type/test", h.testIntegration)
+		api.GET("/secret-audit", h.getSecretAudit)
+
+		// Connections (named integration accounts)
+		api.GET("/connections", h.getConnections)
+		api.POST("/connections", h.createConnection)
+		api.GET("/connections/:id", h.getConnection)
+		api.PUT("/connections/:id", h.updateConnection)
+		api.DELETE("/connections/:id", h.deleteConnection)
+		api.POST("/connections/:id/default", h.makeDefaultConnection)
+		api.POST("/connections/:id/reveal", h.revealIntegrationSecrets)
+		api.POST("/connections/:id/test", h.testConnectionByID)
+		api.POST("/connections/:id/oauth/authorize", h.authorizeOAuthConnection)
+		api.DELETE("/connections/:id/oauth", h.disconnectOAuthConnection)
 
 		// Node dry-run
 		api.POST("/nodes/dry-run", h.dryRunNode)
 
 		// Jira webhook management
 		api.POST("/jira/register-webhook", h.registerJiraWebhook)
+		api.GET("/jira/webhooks", h.getJiraWebhooks)
+		api.POST("/jira/webhooks", h.createJiraWebhook)
+		api.POST("/jira/webhooks/reconcile", h.reconcileJiraWebhooks)
+		api.PUT("/jira/webhooks/:id", h.updateJiraWebhook)
+		api.DELETE("/jira/webhooks/:id", h.deleteJiraWebhook)
 
 		// Webhook events log
 		api.GET("/webhook-events", h.getWebhookEvents)
+		api.GET("/webhook-events/:id", h.getWebhookEvent)
+		api.POST("/webhook-events/:id/replay", h.replayWebhookEvent)
 
 		// Workflow trigger settings
 		api.PUT("/workflows/:id/trigger", h.updateWorkflowTrigger)
+		api.GET("/workflows/:id/triggers", h.getWorkflowTriggers)
+		api.POST("/workflows/:id/triggers", h.createWorkflowTrigger)
+		api.PUT("/workflows/:id/triggers/:triggerId", h.updateWorkflowTriggerByID)
+		api.DELETE("/workflows/:id/triggers/:triggerId", h.deleteWorkflowTrigger)
+		api.POST("/workflows/:id/triggers/:triggerId/run", h.runWorkflowTrigger)
+		api.POST("/workflows/:id/triggers/:triggerId/pause", h.pauseWorkflowTrigger)
+		api.POST("/workflows/:id/triggers/:triggerId/resume", h.resumeWorkflowTrigger)
+		api.GET("/workflows/:id/schedule-skips", h.getScheduleSkips)
+
+		// Backfills
+		api.POST("/workflows/:id/backfill", h.createBackfill)
+		api.GET("/workflows/:id/backfills", h.getWorkflowBackfills)
+		api.GET("/backfills/:id", h.getBackfill)
+		api.POST("/backfills/:id/cancel", h.cancelBackfill)
+
+		// Scheduler
+		api.GET("/scheduler/metrics", h.getSchedulerMetrics)
+		api.POST("/scheduler/pause", h.pauseScheduler)
+		api.POST("/scheduler/resume", h.resumeScheduler)
+
+		// Blackout calendars
+		api.GET("/calendars", h.getCalendars)
+		api.GET("/calendars/:id", h.getCalendar)
+		api.POST("/calendars", h.createCalendar)
+		api.PUT("/calendars/:id", h.updateCalendar)
+		api.DELETE("/calendars/:id", h.deleteCalendar)
 
 		// Environments
 		api.GET("/environments", h.getEnvironments)
@@ -80,6 +146,17 @@ func (h *Handler) RegisterRoutes(r *gin.Engine) {
 
 	// Webhook receivers (public endpoints — no /api prefix)
 	r.POST("/webhooks/jira", h.handleJiraWebhook)
+	r.POST("/webhooks/jira/:connection", h.handleJiraWebhook)
+	r.POST("/webhooks/github", h.handleGitHubWebhook)
+	r.POST("/webhooks/github/:connection", h.handleGitHubWebhook)
+	r.POST("/webhooks/slack/events", h.handleSlackEvents)
+	r.POST("/webhooks/slack/events/:connection", h.handleSlackEvents)
+	r.POST("/webhooks/slack/commands", h.handleSlackCommand)
+	r.POST("/webhooks/slack/commands/:connection", h.handleSlackCommand)
+	r.Any("/webhooks/w/:token", h.handleGenericWebhook)
+
+	// OAuth consent redirect (public — the user's browser lands here)
+	r.GET("/oauth/callback", h.handleOAuthCallback)
 
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
@@ -3,27 +3,53 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
 // ==================== Helpers ====================
 
-// loadIntegrationConfig loads the config JSON for a given integration type.
+// loadIntegrationConfig loads the decrypted config of the default
+// connection of an integration type.
 func (h *Handler) loadIntegrationConfig(iType string) (map[string]interface{}, error) {
-	var configRaw json.RawMessage
-	err := h.db.QueryRow("SELECT config FROM integrations WHERE type = ?", iType).Scan(&configRaw)
-	if err != nil {
-		return nil, err
+	return h.loadConnectionConfig(iType, "")
+}
+
+// splitCommaList splits a comma-separated list, dropping blank entries.
+func splitCommaList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
+}
 
-	var config map[string]interface{}
-	if err := json.Unmarshal(configRaw, &config); err != nil {
-		return nil, err
+// mergeJSONObjects shallow-merges two JSON objects, with keys in overlay
+// winning. Non-object inputs are treated as empty; if both are empty the
+// result is {}.
+func mergeJSONObjects(base, overlay json.RawMessage) json.RawMessage {
+	merged := map[string]interface{}{}
+	json.Unmarshal(base, &merged)
+	var top map[string]interface{}
+	if json.Unmarshal(overlay, &top) == nil {
+		for k, v := range top {
+			merged[k] = v
+		}
 	}
-	return config, nil
+	out, _ := json.Marshal(merged)
+	return out
 }
 
-// templateReplace does simple {{key}} replacement from a map.
+// templatePathPattern matches {{dotted.path}} placeholders, including array
+// indexes such as {{items[0].name}}.
+var templatePathPattern = regexp.MustCompile(`{{\s*([A-Za-z0-9_\-]+(?:\.[A-Za-z0-9_\-]+|\[\d+\])+)\s*}}`)
+
+// templateReplace does simple {{key}} replacement from a map. Placeholders
+// that name a nested path ({{schedule.fire_time}}) are resolved against the
+// nested value; unknown placeholders are left as-is.
 func templateReplace(tmpl string, data map[string]interface{}) string {
 	if data == nil {
 		return tmpl
@@ -34,7 +60,74 @@ func templateReplace(tmpl string, data map[string]interface{}) string {
 		valStr := fmt.Sprintf("%v", val)
 		result = strings.ReplaceAll(result, placeholder, valStr)
 	}
-	return result
+	return templatePathPattern.ReplaceAllStringFunc(result, func(m string) string {
+		path := templatePathPattern.FindStringSubmatch(m)[1]
+		val, ok := lookupPath(data, path)
+		if !ok {
+			return m
+		}
+		switch v := val.(type) {
+		case string:
+			return v
+		case map[string]interface{}, []interface{}:
+			b, _ := json.Marshal(v)
+			return string(b)
+		default:
+			return fmt.Sprintf("%v", v)
+		}
+	})
+}
+
+// templateJSONValue applies templateReplace to every string in a decoded
+// JSON value, leaving its structure intact.
+func templateJSONValue(v interface{}, data map[string]interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		return templateReplace(val, data)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = templateJSONValue(item, data)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = templateJSONValue(item, data)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// lookupPath walks a decoded JSON value along a dotted path. Array elements
+// are addressed as items[0] or items.0.
+func lookupPath(data interface{}, path string) (interface{}, bool) {
+	path = strings.ReplaceAll(strings.ReplaceAll(path, "[", "."), "]", "")
+	cur := data
+	for _, part := range strings.Split(path, ".") {
+		if part == "" {
+			continue
+		}
+		switch node := cur.(type) {
+		case map[string]interface{}:
+			v, ok := node[part]
+			if !ok {
+				return nil, false
+			}
+			cur = v
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			cur = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
 }
 
 // resolveEnvVarsInData replaces {{env.xxx}} placeholders in all string values
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ==================== Trigger Input Mapping ====================
+//
+// A trigger node's input_mapping projects the event payload into the small,
+// named input a run starts with, e.g. for a Jira webhook:
+//
+//	{
+//	  "key": "issue.key",
+//	  "priority": "issue.fields.priority.name",
+//	  "title": "[{{issue.key}}] {{issue.fields.summary}}",
+//	  "reporter": {"name": "issue.fields.reporter.displayName"}
+//	}
+//
+// A string value is a payload path (missing paths give null, "$" is the whole
+// payload), unless it contains {{...}} placeholders, in which case it is a
+// template. Objects are mapped recursively; numbers, booleans and null are
+// used as-is. The unmapped payload is kept in workflow_runs.trigger_payload.
+
+// parseInputMapping reads a node's input_mapping, given either as a JSON
+// object or as its text. It returns nil when the node has none.
+func parseInputMapping(data map[string]interface{}) (map[string]interface{}, error) {
+	switch v := data["input_mapping"].(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return nil, nil
+		}
+		return v, nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil, nil
+		}
+		var mapping map[string]interface{}
+		if err := json.Unmarshal([]byte(v), &mapping); err != nil {
+			return nil, fmt.Errorf("must be a JSON object: %v", err)
+		}
+		return mapping, nil
+	case nil:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("must be a JSON object")
+}
+
+// projectPayload builds the mapped input from a payload.
+func projectPayload(mapping map[string]interface{}, payload map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(mapping))
+	for key, spec := range mapping {
+		switch s := spec.(type) {
+		case string:
+			s = strings.TrimSpace(s)
+			switch {
+			case s == "$":
+				out[key] = payload
+			case strings.Contains(s, "{{"):
+				out[key] = templateReplace(s, payload)
+			default:
+				out[key], _ = lookupPath(payload, s)
+			}
+		case map[string]interface{}:
+			out[key] = projectPayload(s, payload)
+		default:
+			out[key] = s
+		}
+	}
+	return out
+}
+
+// applyInputMapping returns the run input for an event under the node's
+// input_mapping, or nil when the node has no mapping and the payload itself
+// is the input.
+func applyInputMapping(data map[string]interface{}, payload map[string]interface{}) (json.RawMessage, error) {
+	mapping, err := parseInputMapping(data)
+	if err != nil || mapping == nil {
+		return nil, err
+	}
+	out, err := json.Marshal(projectPayload(mapping, payload))
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== Integration Health ====================
+//
+// A connection test makes the cheapest authenticated call each integration
+// type offers and reports who the credentials act as, the granted scopes
+// where the API exposes them, and the round-trip latency. Tests of stored
+// connections also record a health status on the row, refreshed
+// periodically by StartIntegrationHealthChecks.
+
+const (
+	defaultHealthCheckInterval = 15 * time.Minute
+	connectionTestTimeout      = 10 * time.Second
+)
+
+// ConnectionTestResult is the outcome of a connection test.
+type ConnectionTestResult struct {
+	OK        bool                   `json:"ok"`
+	Identity  map[string]interface{} `json:"identity,omitempty"`
+	Scopes    []string               `json:"scopes,omitempty"`
+	LatencyMS int64                  `json:"latency_ms"`
+	Error     string                 `json:"error,omitempty"`
+	CheckedAt time.Time              `json:"checked_at"`
+}
+
+// connectionTester performs the test call for one integration type.
+type connectionTester func(client *http.Client, config map[string]interface{}) (identity map[string]interface{}, scopes []string, err error)
+
+// connectionTesters lists the integration types that can be tested.
+var connectionTesters = map[string]connectionTester{
+	"jira":    testJiraConnection,
+	"slack":   testSlackConnection,
+	"datadog": testDatadogConnection,
+	"github":  testGitHubConnection,
+	"oauth2":  testOAuth2Connection,
+}
+
+// testConnection runs the type's test against a config.
+func testConnection(iType string, config map[string]interface{}) ConnectionTestResult {
+	result := ConnectionTestResult{CheckedAt: time.Now()}
+	tester, ok := connectionTesters[iType]
+	if !ok {
+		result.Error = fmt.Sprintf("connection test is not supported for %s", iType)
+		return result
+	}
+	client := &http.Client{Timeout: connectionTestTimeout}
+	start := time.Now()
+	identity, scopes, err := tester(client, config)
+	result.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.OK = true
+	result.Identity = identity
+	result.Scopes = scopes
+	return result
+}
+
+// splitScopes parses a comma-separated scopes header.
+func splitScopes(header string) []string {
+	return splitCommaList(header)
+}
+
+// fetchJSON sends req and decodes a JSON response, treating any status of 400
+// or above as an error.
+func fetchJSON(client *http.Client, req *http.Request, out interface{}) (*http.Response, error) {
+	req.Header.Set("Accept", ContentTypeJSON)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode >= 400 {
+		return resp, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return resp, fmt.Errorf("unexpected response: %v", err)
+	}
+	return resp, nil
+}
+
+func testJiraConnection(client *http.Client, config map[string]interface{}) (map[string]interface{}, []string, error) {
+	jc, err := jiraClientFromConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	jc.client = client
+	body, err := jc.do("GET", "/rest/api/3/myself", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var me struct {
+		AccountID    string `json:"accountId"`
+		DisplayName  string `json:"displayName"`
+		EmailAddress string `json:"emailAddress"`
+	}
+	if err := json.Unmarshal(body, &me); err != nil {
+		return nil, nil, fmt.Errorf("unexpected response: %v", err)
+	}
+	return map[string]interface{}{
+		"account_id": me.AccountID, "display_name": me.DisplayName, "email": me.EmailAddress, "site": jc.domain,
+	}, nil, nil
+}
+
+func testSlackConnection(client *http.Client, config map[string]interface{}) (map[string]interface{}, []string, error) {
+	botToken := connectionToken(config, "bot_token")
+	if botToken == "" {
+		return nil, nil, fmt.Errorf("config incomplete: need bot_token")
+	}
+	req, err := http.NewRequest("POST", "https://slack.com/api/auth.test", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set(AuthorizationHeader, "Bearer "+botToken)
+	var res struct {
+		OK     bool   `json:"ok"`
+		Error  string `json:"error"`
+		URL    string `json:"url"`
+		Team   string `json:"team"`
+		TeamID string `json:"team_id"`
+		User   string `json:"user"`
+		UserID string `json:"user_id"`
+		BotID  string `json:"bot_id"`
+	}
+	resp, err := fetchJSON(client, req, &res)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !res.OK {
+		return nil, nil, fmt.Errorf("Slack auth.test: %s", res.Error)
+	}
+	return map[string]interface{}{
+		"team": res.Team, "team_id": res.TeamID, "user": res.User, "user_id": res.UserID, "bot_id": res.BotID, "url": res.URL,
+	}, splitScopes(resp.Header.Get("X-OAuth-Scopes")), nil
+}
+
+func testDatadogConnection(client *http.Client, config map[string]interface{}) (map[string]interface{}, []string, error) {
+	apiKey, _ := config["api_key"].(string)
+	if apiKey == "" {
+		return nil, nil, fmt.Errorf("config incomplete: need api_key")
+	}
+	site, _ := config["site"].(string)
+	if site == "" {
+		site = "datadoghq.com"
+	}
+	req, err := http.NewRequest("GET", fmt.Sprintf("https://api.%s/api/v1/validate", site), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("DD-API-KEY", apiKey)
+	var res struct {
+		Valid bool `json:"valid"`
+	}
+	if _, err := fetchJSON(client, req, &res); err != nil {
+		return nil, nil, err
+	}
+	if !res.Valid {
+		return nil, nil, fmt.Errorf("Datadog rejected the API key")
+	}
+	return map[string]interface{}{"site": site}, nil, nil
+}
+
+func testGitHubConnection(client *http.Client, config map[string]interface{}) (map[string]interface{}, []string, error) {
+	token, _ := config["token"].(string)
+	if token == "" {
+		return nil, nil, fmt.Errorf("config incomplete: need token (webhook_secret alone cannot be tested)")
+	}
+	req, err := http.NewRequest("GET", "https://api.github.com/user", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set(AuthorizationHeader, "Bearer "+token)
+	var user struct {
+		Login string `json:"login"`
+		ID    int64  `json:"id"`
+		Name  string `json:"name"`
+	}
+	resp, err := fetchJSON(client, req, &user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return map[string]interface{}{"login": user.Login, "id": user.ID, "name": user.Name},
+		splitScopes(resp.Header.Get("X-OAuth-Scopes")), nil
+}
+
+// testOAuth2Connection checks a generic OAuth connection. With a
+// userinfo_url the token is used against it; otherwise holding a token is
+// all that can be checked.
+func testOAuth2Connection(client *http.Client, config map[string]interface{}) (map[string]interface{}, []string, error) {
+	token := connectionToken(config, "")
+	if token == "" {
+		return nil, nil, errOAuthNotConnected
+	}
+	granted, _ := config["granted_scopes"].(string)
+	scopes := strings.Fields(granted)
+	userinfoURL, _ := config["userinfo_url"].(string)
+	if userinfoURL == "" {
+		return map[string]interface{}{"token_expires_at": config["token_expires_at"]}, scopes, nil
+	}
+	req, err := http.NewRequest("GET", userinfoURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set(AuthorizationHeader, "Bearer "+token)
+	var identity map[string]interface{}
+	if _, err := fetchJSON(client, req, &identity); err != nil {
+		return nil, nil, err
+	}
+	return identity, scopes, nil
+}
+
+// testStoredConnection tests a saved connection and records its health.
+func (h *Handler) testStoredConnection(i Integration) ConnectionTestResult {
+	var config map[string]interface{}
+	json.Unmarshal(i.Config, &config)
+	config, err := h.ensureOAuthToken(i, config)
+	if err != nil {
+		result := ConnectionTestResult{Error: err.Error(), CheckedAt: time.Now()}
+		h.recordConnectionHealth(i.ID, result)
+		return result
+	}
+	result := testConnection(i.Type, config)
+	h.recordConnectionHealth(i.ID, result)
+	return result
+}
+
+// recordConnectionHealth stores a test result as the connection's health.
+func (h *Handler) recordConnectionHealth(id string, result ConnectionTestResult) {
+	status, message := "ok", ""
+	if !result.OK {
+		status, message = "error", result.Error
+	}
+	_, err := h.db.Exec(
+		"UPDATE integrations SET health_status = ?, health_message = ?, health_latency_ms = ?, health_checked_at = ?, updated_at = updated_at WHERE id = ?",
+		status, message, result.LatencyMS, result.CheckedAt, id,
+	)
+	if err != nil {
+		log.Printf("Failed to record health of integration %s: %v", id, err)
+	}
+}
+
+// respondConnectionTest tests a stored connection. A request body with a
+// config tests those values instead, without saving them or touching the
+// recorded health; masked or omitted secrets fall back to the stored ones
+// unless the config points at another host.
+func (h *Handler) respondConnectionTest(c *gin.Context, i Integration) {
+	var req struct {
+		Config json.RawMessage `json:"config"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Config) == 0 {
+		c.JSON(200, h.testStoredConnection(i))
+		return
+	}
+	merged, err := mergeSecretConfig(i.Type, i.Config, req.Config)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var config map[string]interface{}
+	json.Unmarshal(merged, &config)
+	c.JSON(200, testConnection(i.Type, config))
+}
+
+// testIntegration tests the default connection of a type.
+func (h *Handler) testIntegration(c *gin.Context) {
+	iType := c.Param("type")
+	if _, ok := connectionTesters[iType]; !ok {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("Connection test is not supported for %s", iType)})
+		return
+	}
+	i, err := h.defaultConnection(iType)
+	if err == sql.ErrNoRows {
+		c.JSON(404, gin.H{"error": "Integration not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	h.respondConnectionTest(c, i)
+}
+
+// testConnectionByID tests a connection by ID.
+func (h *Handler) testConnectionByID(c *gin.Context) {
+	i, ok := h.findConnection(c)
+	if !ok {
+		return
+	}
+	h.respondConnectionTest(c, i)
+}
+
+// withSaveTest adds a connection test result to a save response when the
+// request asked for one with ?test=true.
+func (h *Handler) withSaveTest(c *gin.Context, id string, resp gin.H) gin.H {
+	if c.Query("test") != "true" {
+		return resp
+	}
+	i, err := h.scanIntegration(h.db.QueryRow("SELECT "+integrationColumns+" FROM integrations WHERE id = ?", id))
+	if err != nil {
+		resp["test"] = ConnectionTestResult{Error: err.Error(), CheckedAt: time.Now()}
+		return resp
+	}
+	resp["test"] = h.testStoredConnection(i)
+	return resp
+}
+
+// StartIntegrationHealthChecks tests every testable connection on an
+// interval from INTEGRATION_HEALTH_INTERVAL (a Go duration, default 15m;
+// "0" disables the checks).
+func (h *Handler) StartIntegrationHealthChecks() {
+	interval := defaultHealthCheckInterval
+	if v := os.Getenv("INTEGRATION_HEALTH_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			log.Printf("Invalid INTEGRATION_HEALTH_INTERVAL %q, using %s", v, interval)
+		} else {
+			interval = d
+		}
+	}
+	if interval == 0 {
+		log.Println("🩺 Integration health checks disabled")
+		return
+	}
+	log.Printf("🩺 Integration health checks every %s", interval)
+
+	for {
+		h.checkIntegrationHealth()
+		time.Sleep(interval)
+	}
+}
+
+func (h *Handler) checkIntegrationHealth() {
+	rows, err := h.db.Query("SELECT " + integrationColumns + " FROM integrations")
+	if err != nil {
+		log.Printf("Failed to load integrations for health check: %v", err)
+		return
+	}
+	var connections []Integration
+	unreadable := map[string]error{}
+	for rows.Next() {
+		i, err := h.scanIntegration(rows)
+		if err != nil {
+			if i.ID != "" {
+				unreadable[i.ID] = err
+			}
+			continue
+		}
+		if _, ok := connectionTesters[i.Type]; ok {
+			connections = append(connections, i)
+		}
+	}
+	rows.Close()
+
+	for id, err := range unreadable {
+		h.recordConnectionHealth(id, ConnectionTestResult{Error: err.Error(), CheckedAt: time.Now()})
+	}
+	for _, i := range connections {
+		if result := h.testStoredConnection(i); !result.OK {
+			log.Printf("🩺 %s connection %q is unhealthy: %s", i.Type, i.Name, result.Error)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== Integration Secrets ====================
+//
+// Secret config fields are never returned by the integrations API: reads
+// show them as secretMask, and an update that omits a secret or sends the
+// mask back keeps the stored value (send "" to clear it). The plain values
+// are only available from the reveal endpoint, which needs the
+// INTEGRATION_ADMIN_TOKEN and records every attempt in
+// integration_secret_audit.
+
+// secretMask replaces a set secret in API responses.
+const secretMask = "********"
+
+// integrationSecretFields declares the secret config fields of each
+// integration type.
+var integrationSecretFields = map[string][]string{
+	"jira":    {"api_token", "webhook_secret", "webhook_token", "client_secret", "access_token", "refresh_token"},
+	"slack":   {"bot_token", "signing_secret", "client_secret", "access_token", "refresh_token"},
+	"github":  {"token", "webhook_secret"},
+	"datadog": {"api_key", "app_key"},
+	"oauth2":  {"client_secret", "access_token", "refresh_token"},
+}
+
+// secretFieldsFor returns the secret fields of an integration type. Types
+// without a declaration treat any key naming a token, secret, password or
+// key as secret, so a new integration is masked by default.
+func secretFieldsFor(iType string, config map[string]interface{}) []string {
+	if fields, ok := integrationSecretFields[iType]; ok {
+		return fields
+	}
+	var fields []string
+	for key := range config {
+		k := strings.ToLower(key)
+		for _, word := range []string{"token", "secret", "password", "api_key", "private_key"} {
+			if strings.Contains(k, word) {
+				fields = append(fields, key)
+				break
+			}
+		}
+	}
+	return fields
+}
+
+// maskIntegration replaces the set secrets in an integration's config with
+// secretMask and lists the secret fields.
+func maskIntegration(i *Integration) {
+	var config map[string]interface{}
+	if json.Unmarshal(i.Config, &config) != nil {
+		return
+	}
+	i.SecretFields = secretFieldsFor(i.Type, config)
+	for _, key := range i.SecretFields {
+		if s, ok := config[key].(string); ok && s != "" {
+			config[key] = secretMask
+		}
+	}
+	i.Config, _ = json.Marshal(config)
+}
+
+// secretHostFields are the config fields that decide which host a
+// connection's secrets are sent to.
+var secretHostFields = []string{"domain", "site", "token_url", "userinfo_url"}
+
+// changedHostField returns the first host field that an update sets to a
+// different value, or "" when the secrets keep going to the same hosts.
+func changedHostField(prev, next map[string]interface{}) string {
+	for _, key := range secretHostFields {
+		if v, sent := next[key]; sent && fmt.Sprint(v) != fmt.Sprint(prev[key]) {
+			return key
+		}
+	}
+	return ""
+}
+
+// mergeSecretConfig applies write-only semantics to an update: a secret
+// that is omitted or still masked keeps its existing value. Stored secrets
+// are only kept while the host fields are unchanged; pointing a connection
+// at another host requires sending its secrets again (OAuth tokens are
+// dropped instead), so they cannot be sent to a host of the caller's choice.
+func mergeSecretConfig(iType string, existing, incoming json.RawMessage) (json.RawMessage, error) {
+	var next map[string]interface{}
+	if err := json.Unmarshal(incoming, &next); err != nil || next == nil {
+		return nil, fmt.Errorf("config must be a JSON object")
+	}
+	var prev map[string]interface{}
+	json.Unmarshal(existing, &prev)
+	moved := changedHostField(prev, next)
+
+	for _, key := range secretFieldsFor(iType, mergeKeys(prev, next)) {
+		v, sent := next[key]
+		if sent && v != secretMask {
+			continue
+		}
+		old, ok := prev[key]
+		switch {
+		case ok && moved != "" && slices.Contains(oauthTokenKeys, key):
+			delete(next, key)
+		case ok && moved != "":
+			return nil, fmt.Errorf("%s changed: %s must be sent again", moved, key)
+		case ok:
+			next[key] = old
+		default:
+			delete(next, key)
+		}
+	}
+	return json.Marshal(next)
+}
+
+// mergeKeys returns a map holding the keys of both maps, for deciding which
+// keys are secret when an undeclared type's config changes shape.
+func mergeKeys(a, b map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(a)+len(b))
+	for k, v := range a {
+		out[k] = v
+	}
+	for k, v := range b {
+		out[k] = v
+	}
+	return out
+}
+
+// revealIntegrationSecrets returns the plain secrets of a connection (by :id)
+// or of a type's default connection (by :type) to a caller holding
+// INTEGRATION_ADMIN_TOKEN (sent as X-Admin-Token). A reason is required;
+// granted and denied requests are both audited.
+func (h *Handler) revealIntegrationSecrets(c *gin.Context) {
+	iType, connectionID := c.Param("type"), c.Param("id")
+	var req struct {
+		Fields []string `json:"fields"`
+		Reason string   `json:"reason"`
+		Actor  string   `json:"actor"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	audit := func(integrationID *string, fields []string, outcome string) {
+		fieldsJSON, _ := json.Marshal(fields)
+		_, err := h.db.Exec(
+			"INSERT INTO integration_secret_audit (integration_id, integration_type, fields, actor, reason, client_ip, outcome) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			integrationID, iType, fieldsJSON, req.Actor, req.Reason, c.ClientIP(), outcome,
+		)
+		if err != nil {
+			log.Printf("Failed to audit secret reveal for %s: %v", iType, err)
+		}
+	}
+
+	var requestedID *string
+	if connectionID != "" {
+		requestedID = &connectionID
+	}
+	adminToken := os.Getenv("INTEGRATION_ADMIN_TOKEN")
+	if adminToken == "" {
+		audit(requestedID, req.Fields, "disabled")
+		c.JSON(403, gin.H{"error": "Secret reveal is disabled: INTEGRATION_ADMIN_TOKEN is not set"})
+		return
+	}
+	if !constantTimeEqual(c.GetHeader("X-Admin-Token"), adminToken) {
+		audit(requestedID, req.Fields, "denied")
+		log.Printf("🚫 Denied secret reveal for %s%s integration from %s", iType, connectionID, c.ClientIP())
+		c.JSON(403, gin.H{"error": "Invalid admin token"})
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		audit(requestedID, req.Fields, "denied")
+		log.Printf("🚫 Denied secret reveal for %s%s integration without a reason (actor %q)", iType, connectionID, req.Actor)
+		c.JSON(400, gin.H{"error": "reason is required"})
+		return
+	}
+
+	var i Integration
+	var err error
+	if connectionID != "" {
+		i, err = h.scanIntegration(h.db.QueryRow("SELECT "+integrationColumns+" FROM integrations WHERE id = ?", connectionID))
+	} else {
+		i, err = h.defaultConnection(iType)
+	}
+	if err == sql.ErrNoRows {
+		c.JSON(404, gin.H{"error": "Integration not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	iType = i.Type
+	var config map[string]interface{}
+	json.Unmarshal(i.Config, &config)
+
+	secretFields := secretFieldsFor(iType, config)
+	fields := req.Fields
+	if len(fields) == 0 {
+		fields = secretFields
+	}
+	secrets := map[string]interface{}{}
+	for _, key := range fields {
+		if !slices.Contains(secretFields, key) {
+			audit(&i.ID, fields, "denied")
+			c.JSON(400, gin.H{"error": fmt.Sprintf("%s is not a secret field of the %s integration", key, iType)})
+			return
+		}
+		secrets[key] = config[key]
+	}
+
+	audit(&i.ID, fields, "revealed")
+	log.Printf("🔓 Revealed %s secrets %v to %q from %s", iType, fields, req.Actor, c.ClientIP())
+	c.JSON(200, gin.H{"type": iType, "secrets": secrets})
+}
+
+// getSecretAudit lists the most recent secret reveal attempts. It needs the
+// same admin token as the reveal endpoint.
+func (h *Handler) getSecretAudit(c *gin.Context) {
+	adminToken := os.Getenv("INTEGRATION_ADMIN_TOKEN")
+	if adminToken == "" || !constantTimeEqual(c.GetHeader("X-Admin-Token"), adminToken) {
+		c.JSON(403, gin.H{"error": "Invalid admin token"})
+		return
+	}
+	rows, err := h.db.Query("SELECT id, integration_id, integration_type, fields, actor, reason, client_ip, outcome, created_at FROM integration_secret_audit ORDER BY id DESC LIMIT 200")
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	entries := []SecretAuditEntry{}
+	for rows.Next() {
+		var e SecretAuditEntry
+		if err := rows.Scan(&e.ID, &e.IntegrationID, &e.IntegrationType, &e.Fields, &e.Actor, &e.Reason, &e.ClientIP, &e.Outcome, &e.CreatedAt); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	c.JSON(200, entries)
+}
@@ -3,15 +3,50 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"log"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 // ==================== Integrations CRUD ====================
+//
+// An integration type (jira, slack, …) can have several named connections,
+// one of which is the type's default. These legacy per-type endpoints act on
+// the default connection; /api/connections manages them all.
+
+// integrationColumns are selected by scanIntegration.
+const integrationColumns = "id, type, name, is_default, " + integrationConfigColumns +
+	", health_status, COALESCE(health_message, ''), health_latency_ms, health_checked_at, created_at, updated_at"
+
+// defaultConnectionWhere selects the default connection of a type, falling
+// back to its oldest connection.
+const defaultConnectionWhere = " FROM integrations WHERE type = ? ORDER BY is_default DESC, created_at LIMIT 1"
+
+// scanIntegration reads an integration row and decrypts its config.
+func (h *Handler) scanIntegration(row interface{ Scan(...interface{}) error }) (Integration, error) {
+	var i Integration
+	var sealed sealedConfig
+	args := append([]interface{}{&i.ID, &i.Type, &i.Name, &i.IsDefault}, sealed.scanArgs()...)
+	args = append(args, &i.HealthStatus, &i.HealthMessage, &i.HealthLatencyMS, &i.HealthCheckedAt)
+	if err := row.Scan(append(args, &i.CreatedAt, &i.UpdatedAt)...); err != nil {
+		return i, err
+	}
+	config, err := h.keys.open(i.ID, sealed)
+	if err != nil {
+		return i, err
+	}
+	i.Config = config
+	return i, nil
+}
+
+// defaultConnection loads the default connection of an integration type.
+func (h *Handler) defaultConnection(iType string) (Integration, error) {
+	return h.scanIntegration(h.db.QueryRow("SELECT "+integrationColumns+defaultConnectionWhere, iType))
+}
 
 func (h *Handler) getIntegrations(c *gin.Context) {
-	rows, err := h.db.Query("SELECT id, type, name, config, created_at, updated_at FROM integrations ORDER BY type")
+	rows, err := h.db.Query("SELECT " + integrationColumns + " FROM integrations ORDER BY type, is_default DESC, name")
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
@@ -20,20 +55,19 @@ func (h *Handler) getIntegrations(c *gin.Context) {
 
 	var integrations []Integration
 	for rows.Next() {
-		var i Integration
-		if err := rows.Scan(&i.ID, &i.Type, &i.Name, &i.Config, &i.CreatedAt, &i.UpdatedAt); err != nil {
+		i, err := h.scanIntegration(rows)
+		if err != nil {
+			log.Printf("Failed to read integration %s: %v", i.ID, err)
 			continue
 		}
+		maskIntegration(&i)
 		integrations = append(integrations, i)
 	}
 	c.JSON(200, integrations)
 }
 
 func (h *Handler) getIntegration(c *gin.Context) {
-	iType := c.Param("type")
-	var i Integration
-	err := h.db.QueryRow("SELECT id, type, name, config, created_at, updated_at FROM integrations WHERE type = ?", iType).
-		Scan(&i.ID, &i.Type, &i.Name, &i.Config, &i.CreatedAt, &i.UpdatedAt)
+	i, err := h.defaultConnection(c.Param("type"))
 	if err == sql.ErrNoRows {
 		c.JSON(404, gin.H{"error": "Integration not found"})
 		return
@@ -42,6 +76,7 @@ func (h *Handler) getIntegration(c *gin.Context) {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
+	maskIntegration(&i)
 	c.JSON(200, i)
 }
 
@@ -56,37 +91,40 @@ func (h *Handler) upsertIntegration(c *gin.Context) {
 		return
 	}
 
-	var existingID string
-	err := h.db.QueryRow("SELECT id FROM integrations WHERE type = ?", iType).Scan(&existingID)
-
+	existing, err := h.defaultConnection(iType)
 	if err == sql.ErrNoRows {
 		id := uuid.New().String()
-		_, err = h.db.Exec("INSERT INTO integrations (id, type, name, config) VALUES (?, ?, ?, ?)",
-			id, iType, req.Name, req.Config)
-		if err != nil {
-			c.JSON(500, gin.H{"error": err.Error()})
+		if status, err := h.insertConnection(id, iType, req.Name, req.Config, true); err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(201, gin.H{"id": id, "message": "Integration created"})
+		c.JSON(201, h.withSaveTest(c, id, gin.H{"id": id, "message": "Integration created"}))
 	} else if err == nil {
-		_, err = h.db.Exec("UPDATE integrations SET name = ?, config = ? WHERE type = ?",
-			req.Name, req.Config, iType)
-		if err != nil {
-			c.JSON(500, gin.H{"error": err.Error()})
+		if status, err := h.updateConnectionConfig(existing, req.Name, req.Config); err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(200, gin.H{"id": existingID, "message": "Integration updated"})
+		c.JSON(200, h.withSaveTest(c, existing.ID, gin.H{"id": existing.ID, "message": "Integration updated"}))
 	} else {
 		c.JSON(500, gin.H{"error": err.Error()})
 	}
 }
 
+// deleteIntegration deletes the type's default connection; another
+// connection of the type, if any, becomes the default.
 func (h *Handler) deleteIntegration(c *gin.Context) {
-	iType := c.Param("type")
-	_, err := h.db.Exec("DELETE FROM integrations WHERE type = ?", iType)
+	i, err := h.defaultConnection(c.Param("type"))
+	if err == sql.ErrNoRows {
+		c.JSON(200, gin.H{"message": "Integration deleted"})
+		return
+	}
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
+	if err := h.removeConnection(i); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
 	c.JSON(200, gin.H{"message": "Integration deleted"})
 }
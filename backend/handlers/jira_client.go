@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ==================== Jira API Client ====================
+
+// jiraClient calls the Jira Cloud REST API with basic auth, or with an
+// OAuth access token through api.atlassian.com.
+type jiraClient struct {
+	domain      string
+	email       string
+	apiToken    string
+	cloudID     string
+	accessToken string
+	client      *http.Client
+}
+
+// jiraAPIError is returned for a non-2xx response; Body is Jira's error
+// payload.
+type jiraAPIError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *jiraAPIError) Error() string {
+	return fmt.Sprintf("Jira API error %d: %s", e.StatusCode, string(e.Body))
+}
+
+func newJiraClient(domain, email, apiToken string) *jiraClient {
+	return &jiraClient{domain: domain, email: email, apiToken: apiToken, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// jiraClientFromIntegration builds a client from the default jira connection.
+// The error text is shown to users as-is.
+func (h *Handler) jiraClientFromIntegration() (*jiraClient, error) {
+	return h.jiraClientForConnection("")
+}
+
+// jiraClientForConnection builds a client from a jira connection, or from
+// the default one when connectionID is empty.
+func (h *Handler) jiraClientForConnection(connectionID string) (*jiraClient, error) {
+	config, err := h.loadConnectionConfig("jira", connectionID)
+	if err != nil {
+		return nil, errors.New(connectionError("Jira", connectionID, err))
+	}
+	return jiraClientFromConfig(config)
+}
+
+// jiraClientFromConfig builds a client from a jira connection config.
+func jiraClientFromConfig(config map[string]interface{}) (*jiraClient, error) {
+	domain, _ := config["domain"].(string)
+	if isOAuthConfig(config) {
+		cloudID, _ := config["cloud_id"].(string)
+		if cloudID == "" {
+			return nil, fmt.Errorf("Jira OAuth connection has no site yet: connect it in Settings → Integrations")
+		}
+		jc := newJiraClient(domain, "", "")
+		jc.cloudID = cloudID
+		jc.accessToken = connectionToken(config, "")
+		return jc, nil
+	}
+	email, _ := config["email"].(string)
+	apiToken, _ := config["api_token"].(string)
+	if domain == "" || email == "" || apiToken == "" {
+		return nil, fmt.Errorf("Jira integration config incomplete: need domain, email, api_token")
+	}
+	return newJiraClient(domain, email, apiToken), nil
+}
+
+// do sends a request to path (e.g. /rest/api/3/issue) with body encoded as
+// JSON when non-nil, and returns the response body. Status codes of 400 and
+// above are returned as *jiraAPIError along with the body.
+func (jc *jiraClient) do(method, path string, body interface{}) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	baseURL := "https://" + jc.domain
+	if jc.cloudID != "" {
+		baseURL = "https://api.atlassian.com/ex/jira/" + jc.cloudID
+	}
+	req, err := http.NewRequest(method, baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create Jira request: %v", err)
+	}
+	if jc.accessToken != "" {
+		req.Header.Set(AuthorizationHeader, "Bearer "+jc.accessToken)
+	} else {
+		req.SetBasicAuth(jc.email, jc.apiToken)
+	}
+	req.Header.Set("Accept", ContentTypeJSON)
+	if body != nil {
+		req.Header.Set(ContentTypeHeader, ContentTypeJSON)
+	}
+
+	resp, err := jc.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Jira API call failed: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 400 {
+		return respBody, &jiraAPIError{StatusCode: resp.StatusCode, Body: respBody}
+	}
+	return respBody, nil
+}
+
+// browseURL is the web link to an issue.
+func (jc *jiraClient) browseURL(key string) string {
+	return fmt.Sprintf("https://%s/browse/%s", jc.domain, key)
+}
@@ -0,0 +1,442 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ==================== Jira Node Executors ====================
+//
+// Nodes that act on existing issues. Like jira_create_issue they use the
+// node's jira connection (or the default one), and their text fields
+// support {{key}} templates against the input.
+
+// Paging limits of the jira_search node.
+const (
+	jiraSearchPageSize   = 100
+	jiraSearchDefaultMax = 100
+	jiraSearchMaxResults = 1000
+)
+
+// jiraNode holds what every Jira node needs: the client of its connection
+// and the templating input.
+type jiraNode struct {
+	jc    *jiraClient
+	data  map[string]interface{}
+	input map[string]interface{}
+}
+
+// newJiraNode resolves the node's connection. The error is shown as-is.
+func (h *Handler) newJiraNode(data map[string]interface{}, input json.RawMessage) (*jiraNode, string) {
+	var inputMap map[string]interface{}
+	json.Unmarshal(input, &inputMap)
+	jc, err := h.jiraClientForConnection(nodeConnectionID(data, inputMap))
+	if err != nil {
+		return nil, err.Error()
+	}
+	return &jiraNode{jc: jc, data: data, input: inputMap}, ""
+}
+
+// str returns a templated, trimmed field. Numbers are formatted as text.
+func (n *jiraNode) str(key string) string {
+	var s string
+	switch v := n.data[key].(type) {
+	case string:
+		s = v
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return strings.TrimSpace(templateReplace(s, n.input))
+}
+
+// issueKey returns the required issue_key field.
+func (n *jiraNode) issueKey(label string) (string, string) {
+	key := n.str("issue_key")
+	if key == "" {
+		return "", label + ": issue_key is required"
+	}
+	return key, ""
+}
+
+// call sends a request and decodes a JSON response into out when non-nil.
+// API errors come back with Jira's error body as the node output.
+func (n *jiraNode) call(method, path string, body, out interface{}) (json.RawMessage, string) {
+	respBody, err := n.jc.do(method, path, body)
+	if err != nil {
+		if _, ok := err.(*jiraAPIError); ok {
+			return jiraErrorOutput(respBody), err.Error()
+		}
+		return nil, err.Error()
+	}
+	if out != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return nil, fmt.Sprintf("Unexpected Jira response: %v", err)
+		}
+	}
+	return respBody, ""
+}
+
+// jiraErrorOutput keeps an error body as output when it is JSON.
+func jiraErrorOutput(body []byte) json.RawMessage {
+	if json.Valid(body) {
+		return json.RawMessage(body)
+	}
+	return nil
+}
+
+// issueOutput is the common output of the issue nodes.
+func (n *jiraNode) issueOutput(key string, extra map[string]interface{}) json.RawMessage {
+	out := map[string]interface{}{"key": key, "browse_url": n.jc.browseURL(key)}
+	for k, v := range extra {
+		out[k] = v
+	}
+	b, _ := json.Marshal(out)
+	return b
+}
+
+// jiraADF turns a text field into an Atlassian Document Format body. Text
+// that is an ADF document ({"type": "doc", ...}) is used as-is; anything
+// else, including wiki markup such as {code}, becomes plain paragraphs.
+func jiraADF(text string) map[string]interface{} {
+	var doc map[string]interface{}
+	if json.Unmarshal([]byte(text), &doc) == nil && doc["type"] == "doc" {
+		return doc
+	}
+	return convertTextToADF(text)
+}
+
+// parseJiraJSONObject parses an optional JSON object field such as
+// fields_json. Empty input gives an empty map.
+func parseJiraJSONObject(label, src string) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	if strings.TrimSpace(src) == "" {
+		return out, nil
+	}
+	if err := json.Unmarshal([]byte(src), &out); err != nil || out == nil {
+		return nil, fmt.Errorf("%s must be a JSON object", label)
+	}
+	return out, nil
+}
+
+// resolveAccountID accepts an account ID or an email address; emails are
+// looked up with the user search API.
+func (n *jiraNode) resolveAccountID(user string) (string, string) {
+	if !strings.Contains(user, "@") {
+		return user, ""
+	}
+	var users []struct {
+		AccountID    string `json:"accountId"`
+		EmailAddress string `json:"emailAddress"`
+	}
+	if _, errMsg := n.call("GET", "/rest/api/3/user/search?query="+url.QueryEscape(user), nil, &users); errMsg != "" {
+		return "", errMsg
+	}
+	for _, u := range users {
+		if strings.EqualFold(u.EmailAddress, user) {
+			return u.AccountID, ""
+		}
+	}
+	// Emails are hidden unless users allow it; a single hit is still a match.
+	if len(users) == 1 {
+		return users[0].AccountID, ""
+	}
+	return "", fmt.Sprintf("No single Jira user found for %s", user)
+}
+
+// executeJiraUpdateIssue sets fields of an issue. The form fields cover the
+// common ones; fields_json adds or overrides any field by ID.
+func (h *Handler) executeJiraUpdateIssue(data map[string]interface{}, input json.RawMessage) (json.RawMessage, string) {
+	n, errMsg := h.newJiraNode(data, input)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+	key, errMsg := n.issueKey("Jira Update Issue")
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	extra, err := parseJiraJSONObject("Jira Update Issue: fields_json", n.str("fields_json"))
+	if err != nil {
+		return nil, err.Error()
+	}
+	fields := map[string]interface{}{}
+	if summary := n.str("summary"); summary != "" {
+		fields["summary"] = summary
+	}
+	if description := n.str("description"); description != "" {
+		fields["description"] = jiraADF(description)
+	}
+	if priority := n.str("priority"); priority != "" {
+		fields["priority"] = map[string]string{"name": priority}
+	}
+	if labels := n.str("labels"); labels != "" {
+		fields["labels"] = splitCommaList(labels)
+	}
+	for k, v := range extra {
+		fields[k] = v
+	}
+	if len(fields) == 0 {
+		return nil, "Jira Update Issue: nothing to update"
+	}
+
+	notify := "true"
+	if data["notify_users"] == false || data["notify_users"] == "false" {
+		notify = "false"
+	}
+	path := fmt.Sprintf("/rest/api/3/issue/%s?notifyUsers=%s", url.PathEscape(key), notify)
+	if out, errMsg := n.call("PUT", path, map[string]interface{}{"fields": fields}, nil); errMsg != "" {
+		return out, errMsg
+	}
+
+	updated := make([]string, 0, len(fields))
+	for k := range fields {
+		updated = append(updated, k)
+	}
+	log.Printf("🎫 Jira issue %s updated (%s)", key, strings.Join(updated, ", "))
+	return n.issueOutput(key, map[string]interface{}{"updated_fields": updated}), ""
+}
+
+// jiraTransition is an entry of the issue transitions API.
+type jiraTransition struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	To   struct {
+		Name string `json:"name"`
+	} `json:"to"`
+}
+
+// executeJiraTransitionIssue moves an issue to a status, given by the
+// target status name or the transition name. The transition ID is looked up
+// since it differs between workflows.
+func (h *Handler) executeJiraTransitionIssue(data map[string]interface{}, input json.RawMessage) (json.RawMessage, string) {
+	n, errMsg := h.newJiraNode(data, input)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+	key, errMsg := n.issueKey("Jira Transition Issue")
+	if errMsg != "" {
+		return nil, errMsg
+	}
+	status := n.str("status")
+	if status == "" {
+		return nil, "Jira Transition Issue: status is required"
+	}
+
+	var available struct {
+		Transitions []jiraTransition `json:"transitions"`
+	}
+	if out, errMsg := n.call("GET", fmt.Sprintf("/rest/api/3/issue/%s/transitions", url.PathEscape(key)), nil, &available); errMsg != "" {
+		return out, errMsg
+	}
+	transition, ok := findJiraTransition(available.Transitions, status)
+	if !ok {
+		var names []string
+		for _, t := range available.Transitions {
+			names = append(names, fmt.Sprintf("%q", t.To.Name))
+		}
+		return nil, fmt.Sprintf("Jira Transition Issue: %s cannot move to %q; available: %s", key, status, strings.Join(names, ", "))
+	}
+
+	payload := map[string]interface{}{"transition": map[string]string{"id": transition.ID}}
+	if resolution := n.str("resolution"); resolution != "" {
+		payload["fields"] = map[string]interface{}{"resolution": map[string]string{"name": resolution}}
+	}
+	if comment := n.str("comment"); comment != "" {
+		payload["update"] = map[string]interface{}{
+			"comment": []interface{}{map[string]interface{}{"add": map[string]interface{}{"body": jiraADF(comment)}}},
+		}
+	}
+	if out, errMsg := n.call("POST", fmt.Sprintf("/rest/api/3/issue/%s/transitions", url.PathEscape(key)), payload, nil); errMsg != "" {
+		return out, errMsg
+	}
+
+	log.Printf("🎫 Jira issue %s transitioned to %s", key, transition.To.Name)
+	return n.issueOutput(key, map[string]interface{}{
+		"transition_id": transition.ID, "transition": transition.Name, "status": transition.To.Name,
+	}), ""
+}
+
+// findJiraTransition picks the transition leading to a status, falling back
+// to one with that name. Names compare case-insensitively.
+func findJiraTransition(transitions []jiraTransition, status string) (jiraTransition, bool) {
+	for _, t := range transitions {
+		if strings.EqualFold(t.To.Name, status) {
+			return t, true
+		}
+	}
+	for _, t := range transitions {
+		if strings.EqualFold(t.Name, status) || t.ID == status {
+			return t, true
+		}
+	}
+	return jiraTransition{}, false
+}
+
+// executeJiraAddComment comments on an issue. The comment is plain text
+// (one paragraph per line) or an ADF document.
+func (h *Handler) executeJiraAddComment(data map[string]interface{}, input json.RawMessage) (json.RawMessage, string) {
+	n, errMsg := h.newJiraNode(data, input)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+	key, errMsg := n.issueKey("Jira Add Comment")
+	if errMsg != "" {
+		return nil, errMsg
+	}
+	text := n.str("comment")
+	if text == "" {
+		return nil, "Jira Add Comment: comment is required"
+	}
+	payload := map[string]interface{}{"body": jiraADF(text)}
+	if role := n.str("visibility_role"); role != "" {
+		payload["visibility"] = map[string]string{"type": "role", "value": role}
+	}
+	var comment struct {
+		ID string `json:"id"`
+	}
+	if out, errMsg := n.call("POST", fmt.Sprintf("/rest/api/3/issue/%s/comment", url.PathEscape(key)), payload, &comment); errMsg != "" {
+		return out, errMsg
+	}
+
+	log.Printf("🎫 Comment %s added to Jira issue %s", comment.ID, key)
+	return n.issueOutput(key, map[string]interface{}{
+		"comment_id":  comment.ID,
+		"comment_url": fmt.Sprintf("%s?focusedCommentId=%s", n.jc.browseURL(key), comment.ID),
+	}), ""
+}
+
+// executeJiraAssignIssue assigns an issue to an account ID or email. An
+// empty assignee unassigns it; "default" uses the project's default
+// assignee.
+func (h *Handler) executeJiraAssignIssue(data map[string]interface{}, input json.RawMessage) (json.RawMessage, string) {
+	n, errMsg := h.newJiraNode(data, input)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+	key, errMsg := n.issueKey("Jira Assign Issue")
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	var accountID interface{}
+	switch assignee := n.str("assignee"); strings.ToLower(assignee) {
+	case "", "unassigned", "none":
+		accountID = nil
+	case "default":
+		accountID = "-1"
+	default:
+		id, errMsg := n.resolveAccountID(assignee)
+		if errMsg != "" {
+			return nil, "Jira Assign Issue: " + errMsg
+		}
+		accountID = id
+	}
+	if out, errMsg := n.call("PUT", fmt.Sprintf("/rest/api/3/issue/%s/assignee", url.PathEscape(key)), map[string]interface{}{"accountId": accountID}, nil); errMsg != "" {
+		return out, errMsg
+	}
+
+	log.Printf("🎫 Jira issue %s assigned to %v", key, accountID)
+	return n.issueOutput(key, map[string]interface{}{"assignee": accountID}), ""
+}
+
+// executeJiraLinkIssues links two issues with a link type given by name
+// (Relates, Blocks, …); the outward issue is the one on the outward side of
+// the type.
+func (h *Handler) executeJiraLinkIssues(data map[string]interface{}, input json.RawMessage) (json.RawMessage, string) {
+	n, errMsg := h.newJiraNode(data, input)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+	outward, inward, linkType := n.str("outward_issue"), n.str("inward_issue"), n.str("link_type")
+	if outward == "" || inward == "" {
+		return nil, "Jira Link Issues: outward_issue and inward_issue are required"
+	}
+	if linkType == "" {
+		linkType = "Relates"
+	}
+
+	payload := map[string]interface{}{
+		"type":         map[string]string{"name": linkType},
+		"outwardIssue": map[string]string{"key": outward},
+		"inwardIssue":  map[string]string{"key": inward},
+	}
+	if comment := n.str("comment"); comment != "" {
+		payload["comment"] = map[string]interface{}{"body": jiraADF(comment)}
+	}
+	if out, errMsg := n.call("POST", "/rest/api/3/issueLink", payload, nil); errMsg != "" {
+		return out, errMsg
+	}
+
+	log.Printf("🎫 Jira issues linked: %s %s %s", outward, linkType, inward)
+	out, _ := json.Marshal(map[string]interface{}{
+		"outward_issue": outward, "inward_issue": inward, "link_type": linkType,
+	})
+	return out, ""
+}
+
+// executeJiraSearch runs a JQL query and follows the result pages up to
+// max_results issues. The issues are output as an array under "issues".
+func (h *Handler) executeJiraSearch(data map[string]interface{}, input json.RawMessage) (json.RawMessage, string) {
+	n, errMsg := h.newJiraNode(data, input)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+	jql := n.str("jql")
+	if jql == "" {
+		return nil, "Jira Search: jql is required"
+	}
+	fields := splitCommaList(n.str("fields"))
+	if len(fields) == 0 {
+		fields = []string{"summary", "status", "assignee", "priority", "issuetype", "created", "updated"}
+	}
+	maxResults := jiraSearchDefaultMax
+	if v, err := strconv.Atoi(n.str("max_results")); err == nil && v > 0 {
+		maxResults = min(v, jiraSearchMaxResults)
+	}
+
+	issues := []map[string]interface{}{}
+	pageToken := ""
+	truncated := false
+	for {
+		req := map[string]interface{}{
+			"jql":        jql,
+			"fields":     fields,
+			"maxResults": min(jiraSearchPageSize, maxResults-len(issues)),
+		}
+		if pageToken != "" {
+			req["nextPageToken"] = pageToken
+		}
+		var page struct {
+			Issues        []map[string]interface{} `json:"issues"`
+			NextPageToken string                   `json:"nextPageToken"`
+			IsLast        bool                     `json:"isLast"`
+		}
+		if out, errMsg := n.call("POST", "/rest/api/3/search/jql", req, &page); errMsg != "" {
+			return out, errMsg
+		}
+		for _, issue := range page.Issues {
+			if key, ok := issue["key"].(string); ok {
+				issue["browse_url"] = n.jc.browseURL(key)
+			}
+			issues = append(issues, issue)
+		}
+		if page.IsLast || page.NextPageToken == "" || len(page.Issues) == 0 {
+			break
+		}
+		if len(issues) >= maxResults {
+			truncated = true
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+
+	log.Printf("🔎 Jira search returned %d issue(s)", len(issues))
+	out, _ := json.Marshal(map[string]interface{}{
+		"issues": issues, "count": len(issues), "truncated": truncated, "jql": jql,
+	})
+	return out, ""
+}
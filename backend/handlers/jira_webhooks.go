@@ -0,0 +1,526 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ==================== Jira Webhook Registration ====================
+
+const jiraWebhookAPIPath = "/rest/webhooks/1.0/webhook"
+
+var defaultJiraWebhookEvents = []string{"jira:issue_created", "jira:issue_updated", "jira:issue_deleted"}
+
+const jiraWebhookColumns = "id, jira_webhook_id, COALESCE(connection_id, ''), name, url, events, COALESCE(jql_filter, ''), workflow_id, signed, status, drift, last_reconciled_at, created_at, updated_at"
+
+func scanJiraWebhook(scanner interface{ Scan(...interface{}) error }) (JiraWebhook, error) {
+	var wh JiraWebhook
+	err := scanner.Scan(&wh.ID, &wh.JiraWebhookID, &wh.ConnectionID, &wh.Name, &wh.URL, &wh.Events, &wh.JQLFilter, &wh.WorkflowID, &wh.Signed, &wh.Status, &wh.Drift, &wh.LastReconciledAt, &wh.CreatedAt, &wh.UpdatedAt)
+	return wh, err
+}
+
+func (h *Handler) fetchJiraWebhook(id string) (JiraWebhook, error) {
+	return scanJiraWebhook(h.db.QueryRow("SELECT "+jiraWebhookColumns+" FROM jira_webhooks WHERE id = ?", id))
+}
+
+// respondJiraError passes a Jira API error status through to the caller.
+func respondJiraError(c *gin.Context, err error) {
+	if apiErr, ok := err.(*jiraAPIError); ok {
+		log.Printf("❌ Jira webhook API call failed (%d): %s", apiErr.StatusCode, string(apiErr.Body))
+		c.JSON(apiErr.StatusCode, gin.H{
+			"error":   fmt.Sprintf("Jira API returned %d", apiErr.StatusCode),
+			"details": string(apiErr.Body),
+		})
+		return
+	}
+	c.JSON(500, gin.H{"error": err.Error()})
+}
+
+// jiraWebhookConnection resolves the jira connection a webhook is managed
+// through (the default one when connectionID is empty), returning its ID, a
+// client and the webhook_secret deliveries are signed with.
+func (h *Handler) jiraWebhookConnection(connectionID string) (string, *jiraClient, string, error) {
+	i, config, err := h.storedConnectionConfig("jira", connectionID)
+	if err != nil {
+		return "", nil, "", errors.New(connectionError("Jira", connectionID, err))
+	}
+	jc, err := h.jiraClientForConnection(i.ID)
+	if err != nil {
+		return "", nil, "", err
+	}
+	secret, _ := config["webhook_secret"].(string)
+	return i.ID, jc, secret, nil
+}
+
+// jiraWebhookURL points a URL at this platform's receiver to the
+// connection's own route, /webhooks/jira/<connection>, so deliveries are
+// verified with that connection's secret. Other URLs are left alone.
+func jiraWebhookURL(url, connectionID string) string {
+	base := strings.TrimRight(url, "/")
+	if connectionID == "" || !strings.HasSuffix(base, "/webhooks/jira") {
+		return url
+	}
+	return base + "/" + connectionID
+}
+
+// jiraWebhookDefinition is the body Jira's webhook API expects. Deliveries
+// are signed with secret, the webhook's connection's webhook_secret, which
+// handleJiraWebhook verifies against.
+func jiraWebhookDefinition(name, url string, events []string, jql, secret string) (map[string]interface{}, bool) {
+	def := map[string]interface{}{
+		"name":   name,
+		"url":    url,
+		"events": events,
+	}
+	if jql != "" {
+		def["filters"] = map[string]interface{}{"issue-related-events-section": jql}
+	}
+	if secret != "" {
+		def["secret"] = secret
+	}
+	return def, secret != ""
+}
+
+// jiraWebhookIDFromSelf extracts the webhook ID from the "self" URL Jira
+// returns, e.g. https://x.atlassian.net/rest/webhooks/1.0/webhook/42.
+func jiraWebhookIDFromSelf(self string) string {
+	parts := strings.Split(strings.TrimRight(self, "/"), "/")
+	return parts[len(parts)-1]
+}
+
+type jiraWebhookRequest struct {
+	ConnectionID string   `json:"connection_id"`
+	URL          string   `json:"url"`
+	Events       []string `json:"events"`
+	JQLFilter    string   `json:"jql_filter"`
+	WorkflowID   *string  `json:"workflow_id"`
+}
+
+// registerWebhookOnJira creates the webhook on Jira and records it under
+// req.ConnectionID, signed with secret.
+func (h *Handler) registerWebhookOnJira(jc *jiraClient, secret string, req jiraWebhookRequest) (JiraWebhook, error) {
+	if len(req.Events) == 0 {
+		req.Events = defaultJiraWebhookEvents
+	}
+	req.URL = jiraWebhookURL(req.URL, req.ConnectionID)
+	name := fmt.Sprintf("workflow-platform-%s", uuid.New().String()[:8])
+	def, signed := jiraWebhookDefinition(name, req.URL, req.Events, req.JQLFilter, secret)
+
+	respBody, err := jc.do("POST", jiraWebhookAPIPath, def)
+	if err != nil {
+		return JiraWebhook{}, err
+	}
+	var jiraResp struct {
+		Self string `json:"self"`
+	}
+	json.Unmarshal(respBody, &jiraResp)
+	jiraID := jiraWebhookIDFromSelf(jiraResp.Self)
+
+	id := uuid.New().String()
+	events, _ := json.Marshal(req.Events)
+	var connectionID *string
+	if req.ConnectionID != "" {
+		connectionID = &req.ConnectionID
+	}
+	_, err = h.db.Exec(
+		"INSERT INTO jira_webhooks (id, jira_webhook_id, connection_id, name, url, events, jql_filter, workflow_id, signed) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		id, jiraID, connectionID, name, req.URL, events, req.JQLFilter, req.WorkflowID, signed,
+	)
+	if err != nil {
+		return JiraWebhook{}, fmt.Errorf("webhook %s was created on Jira but could not be saved: %v", jiraID, err)
+	}
+	log.Printf("✅ Jira webhook registered: name=%s, id=%s, url=%s", name, jiraID, req.URL)
+	return h.fetchJiraWebhook(id)
+}
+
+// registerJiraWebhook is the original registration endpoint. It still
+// accepts credentials in the body but falls back to the jira connection
+// given by connection_id, or the default one. Webhooks registered with body
+// credentials are recorded without a connection; they are signed with the
+// given (or default) connection's secret when one exists, since that is what
+// their receiver verifies against.
+func (h *Handler) registerJiraWebhook(c *gin.Context) {
+	var req struct {
+		jiraWebhookRequest
+		JiraDomain   string `json:"jira_domain"`
+		JiraEmail    string `json:"jira_email"`
+		JiraAPIToken string `json:"jira_api_token"`
+		WebhookURL   string `json:"webhook_url"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if req.WebhookURL != "" {
+		req.URL = req.WebhookURL
+	}
+	if req.URL == "" {
+		c.JSON(400, gin.H{"error": "webhook_url is required"})
+		return
+	}
+
+	var jc *jiraClient
+	var secret string
+	if req.JiraDomain != "" || req.JiraEmail != "" || req.JiraAPIToken != "" {
+		if req.JiraDomain == "" || req.JiraEmail == "" || req.JiraAPIToken == "" {
+			c.JSON(400, gin.H{"error": "jira_domain, jira_email, and jira_api_token must be given together"})
+			return
+		}
+		jc = newJiraClient(req.JiraDomain, req.JiraEmail, req.JiraAPIToken)
+		if _, config, err := h.storedConnectionConfig("jira", req.ConnectionID); err == nil {
+			secret, _ = config["webhook_secret"].(string)
+		} else if req.ConnectionID != "" {
+			c.JSON(400, gin.H{"error": connectionError("Jira", req.ConnectionID, err)})
+			return
+		}
+		req.URL = jiraWebhookURL(req.URL, req.ConnectionID)
+		req.ConnectionID = ""
+	} else {
+		var err error
+		if req.ConnectionID, jc, secret, err = h.jiraWebhookConnection(req.ConnectionID); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	wh, err := h.registerWebhookOnJira(jc, secret, req.jiraWebhookRequest)
+	if err != nil {
+		respondJiraError(c, err)
+		return
+	}
+	c.JSON(201, gin.H{
+		"message":    "Webhook registered on Jira successfully",
+		"id":         wh.ID,
+		"name":       wh.Name,
+		"webhook_id": wh.JiraWebhookID,
+		"events":     wh.Events,
+		"signed":     wh.Signed,
+	})
+}
+
+func (h *Handler) getJiraWebhooks(c *gin.Context) {
+	rows, err := h.db.Query("SELECT " + jiraWebhookColumns + " FROM jira_webhooks ORDER BY created_at DESC")
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	webhooks := []JiraWebhook{}
+	for rows.Next() {
+		wh, err := scanJiraWebhook(rows)
+		if err != nil {
+			continue
+		}
+		webhooks = append(webhooks, wh)
+	}
+	c.JSON(200, webhooks)
+}
+
+// checkWorkflowRef reports an error message if a linked workflow is missing.
+func (h *Handler) checkWorkflowRef(workflowID *string) string {
+	if workflowID == nil || *workflowID == "" {
+		return ""
+	}
+	var exists int
+	if h.db.QueryRow("SELECT 1 FROM workflows WHERE id = ?", *workflowID).Scan(&exists) != nil {
+		return "Workflow not found"
+	}
+	return ""
+}
+
+func (h *Handler) createJiraWebhook(c *gin.Context) {
+	var req jiraWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if req.URL == "" {
+		c.JSON(400, gin.H{"error": "url is required"})
+		return
+	}
+	if req.WorkflowID != nil && *req.WorkflowID == "" {
+		req.WorkflowID = nil
+	}
+	if msg := h.checkWorkflowRef(req.WorkflowID); msg != "" {
+		c.JSON(400, gin.H{"error": msg})
+		return
+	}
+	connectionID, jc, secret, err := h.jiraWebhookConnection(req.ConnectionID)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	req.ConnectionID = connectionID
+
+	wh, err := h.registerWebhookOnJira(jc, secret, req)
+	if err != nil {
+		respondJiraError(c, err)
+		return
+	}
+	c.JSON(201, wh)
+}
+
+// updateJiraWebhook changes a registered webhook on Jira and locally.
+// Omitted fields keep their current value; workflow_id "" unlinks.
+func (h *Handler) updateJiraWebhook(c *gin.Context) {
+	wh, err := h.fetchJiraWebhook(c.Param("id"))
+	if err == sql.ErrNoRows {
+		c.JSON(404, gin.H{"error": "Jira webhook not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req struct {
+		URL        *string  `json:"url"`
+		Events     []string `json:"events"`
+		JQLFilter  *string  `json:"jql_filter"`
+		WorkflowID *string  `json:"workflow_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	url, jql, workflowID := wh.URL, wh.JQLFilter, wh.WorkflowID
+	var events []string
+	json.Unmarshal(wh.Events, &events)
+	if req.URL != nil {
+		url = *req.URL
+	}
+	if req.Events != nil {
+		events = req.Events
+	}
+	if req.JQLFilter != nil {
+		jql = *req.JQLFilter
+	}
+	if req.WorkflowID != nil {
+		workflowID = req.WorkflowID
+		if *workflowID == "" {
+			workflowID = nil
+		}
+	}
+	if url == "" || len(events) == 0 {
+		c.JSON(400, gin.H{"error": "url and events cannot be empty"})
+		return
+	}
+	if msg := h.checkWorkflowRef(workflowID); msg != "" {
+		c.JSON(400, gin.H{"error": msg})
+		return
+	}
+
+	_, jc, secret, err := h.jiraWebhookConnection(wh.ConnectionID)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	url = jiraWebhookURL(url, wh.ConnectionID)
+	def, signed := jiraWebhookDefinition(wh.Name, url, events, jql, secret)
+	if _, err := jc.do("PUT", jiraWebhookAPIPath+"/"+wh.JiraWebhookID, def); err != nil {
+		respondJiraError(c, err)
+		return
+	}
+
+	eventsJSON, _ := json.Marshal(events)
+	_, err = h.db.Exec(
+		"UPDATE jira_webhooks SET url = ?, events = ?, jql_filter = ?, workflow_id = ?, signed = ?, status = 'active', drift = NULL WHERE id = ?",
+		url, eventsJSON, jql, workflowID, signed, wh.ID,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	updated, _ := h.fetchJiraWebhook(wh.ID)
+	c.JSON(200, updated)
+}
+
+// deleteJiraWebhook removes the webhook from Jira (tolerating one already
+// deleted there) and forgets it.
+func (h *Handler) deleteJiraWebhook(c *gin.Context) {
+	wh, err := h.fetchJiraWebhook(c.Param("id"))
+	if err == sql.ErrNoRows {
+		c.JSON(404, gin.H{"error": "Jira webhook not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	_, jc, _, err := h.jiraWebhookConnection(wh.ConnectionID)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := jc.do("DELETE", jiraWebhookAPIPath+"/"+wh.JiraWebhookID, nil); err != nil {
+		if apiErr, ok := err.(*jiraAPIError); !ok || apiErr.StatusCode != 404 {
+			respondJiraError(c, err)
+			return
+		}
+	}
+	if _, err := h.db.Exec("DELETE FROM jira_webhooks WHERE id = ?", wh.ID); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("🗑️ Jira webhook %s (%s) deleted", wh.Name, wh.JiraWebhookID)
+	c.JSON(200, gin.H{"message": "Jira webhook deleted"})
+}
+
+// remoteJiraWebhook is a webhook as listed by Jira.
+type remoteJiraWebhook struct {
+	Name    string            `json:"name"`
+	URL     string            `json:"url"`
+	Events  []string          `json:"events"`
+	Filters map[string]string `json:"filters"`
+	Enabled *bool             `json:"enabled"`
+	Self    string            `json:"self"`
+}
+
+// jiraWebhookDrift lists the fields where Jira's copy differs from ours.
+func jiraWebhookDrift(local JiraWebhook, remote remoteJiraWebhook) map[string]interface{} {
+	drift := map[string]interface{}{}
+	if remote.URL != local.URL {
+		drift["url"] = remote.URL
+	}
+	var localEvents []string
+	json.Unmarshal(local.Events, &localEvents)
+	remoteEvents := append([]string(nil), remote.Events...)
+	sort.Strings(localEvents)
+	sort.Strings(remoteEvents)
+	if strings.Join(localEvents, ",") != strings.Join(remoteEvents, ",") {
+		drift["events"] = remote.Events
+	}
+	if jql := remote.Filters["issue-related-events-section"]; strings.TrimSpace(jql) != strings.TrimSpace(local.JQLFilter) {
+		drift["jql_filter"] = jql
+	}
+	if remote.Enabled != nil && !*remote.Enabled {
+		drift["enabled"] = false
+	}
+	return drift
+}
+
+// reconcileJiraWebhooks compares every recorded webhook with the Jira site
+// of its connection, marking ones deleted on Jira as missing and ones changed
+// there as drifted. Jira webhooks that point at this platform's receiver but
+// are not recorded are reported as untracked. Webhooks recorded without a
+// connection are checked against the default one. A connection that cannot
+// be reached is reported under errors and its webhooks are left as they were.
+func (h *Handler) reconcileJiraWebhooks(c *gin.Context) {
+	rows, err := h.db.Query("SELECT id, is_default FROM integrations WHERE type = 'jira' ORDER BY created_at")
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	var connections []string
+	var defaultID string
+	for rows.Next() {
+		var id string
+		var isDefault bool
+		if rows.Scan(&id, &isDefault) == nil {
+			connections = append(connections, id)
+			if isDefault {
+				defaultID = id
+			}
+		}
+	}
+	rows.Close()
+	if len(connections) == 0 {
+		c.JSON(400, gin.H{"error": "Jira integration not configured"})
+		return
+	}
+
+	rows, err = h.db.Query("SELECT " + jiraWebhookColumns + " FROM jira_webhooks")
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	local := map[string][]JiraWebhook{}
+	for rows.Next() {
+		if wh, err := scanJiraWebhook(rows); err == nil {
+			connectionID := wh.ConnectionID
+			if connectionID == "" {
+				connectionID = defaultID
+			}
+			local[connectionID] = append(local[connectionID], wh)
+		}
+	}
+	rows.Close()
+
+	counts := map[string]int{"active": 0, "missing": 0, "drifted": 0}
+	checked := 0
+	untracked := []gin.H{}
+	errs := []gin.H{}
+	for _, connectionID := range connections {
+		remote, err := h.fetchRemoteJiraWebhooks(connectionID)
+		if err != nil {
+			errs = append(errs, gin.H{"connection_id": connectionID, "error": err.Error()})
+			continue
+		}
+
+		tracked := map[string]bool{}
+		for _, wh := range local[connectionID] {
+			tracked[wh.JiraWebhookID] = true
+			status := "active"
+			var drift []byte
+			if r, ok := remote[wh.JiraWebhookID]; !ok {
+				status = "missing"
+			} else if d := jiraWebhookDrift(wh, r); len(d) > 0 {
+				status = "drifted"
+				drift, _ = json.Marshal(d)
+			}
+			checked++
+			counts[status]++
+			h.db.Exec("UPDATE jira_webhooks SET status = ?, drift = ?, last_reconciled_at = NOW() WHERE id = ?", status, drift, wh.ID)
+			if status != wh.Status {
+				log.Printf("🔄 Jira webhook %s (%s): %s → %s", wh.Name, wh.JiraWebhookID, wh.Status, status)
+			}
+		}
+
+		for id, r := range remote {
+			if !tracked[id] && strings.Contains(r.URL, "/webhooks/jira") {
+				untracked = append(untracked, gin.H{"connection_id": connectionID, "jira_webhook_id": id, "name": r.Name, "url": r.URL, "events": r.Events})
+			}
+		}
+	}
+
+	c.JSON(200, gin.H{
+		"checked":   checked,
+		"active":    counts["active"],
+		"missing":   counts["missing"],
+		"drifted":   counts["drifted"],
+		"untracked": untracked,
+		"errors":    errs,
+	})
+}
+
+// fetchRemoteJiraWebhooks lists the webhooks on a connection's Jira site by
+// webhook ID.
+func (h *Handler) fetchRemoteJiraWebhooks(connectionID string) (map[string]remoteJiraWebhook, error) {
+	jc, err := h.jiraClientForConnection(connectionID)
+	if err != nil {
+		return nil, err
+	}
+	body, err := jc.do("GET", jiraWebhookAPIPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	var remoteList []remoteJiraWebhook
+	if err := json.Unmarshal(body, &remoteList); err != nil {
+		return nil, fmt.Errorf("unexpected response from Jira webhook API")
+	}
+	remote := make(map[string]remoteJiraWebhook, len(remoteList))
+	for _, r := range remoteList {
+		remote[jiraWebhookIDFromSelf(r.Self)] = r
+	}
+	return remote, nil
+}
@@ -0,0 +1,423 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ==================== JQL Filter ====================
+//
+// A practical subset of JQL evaluated locally against the issue in a Jira
+// webhook payload:
+//
+//	project = WOP AND issuetype IN (Bug, Task) AND NOT labels = wontfix
+//	status != Done OR priority IN ("High", Highest)
+//	assignee IS EMPTY AND summary ~ "outage"
+//
+// Fields: project, issuetype (type), status, priority, labels, assignee,
+// reporter, resolution, component, key (issuekey), summary.
+// Operators: =, !=, IN, NOT IN, ~, !~, IS [NOT] EMPTY|NULL, with AND, OR, NOT
+// and parentheses. Comparisons are case-insensitive; ORDER BY is ignored.
+
+type jqlNode interface {
+	eval(issue map[string]interface{}) bool
+}
+
+type jqlAnd struct{ left, right jqlNode }
+type jqlOr struct{ left, right jqlNode }
+type jqlNot struct{ inner jqlNode }
+
+type jqlClause struct {
+	field  string
+	op     string
+	values []string
+}
+
+func (n jqlAnd) eval(issue map[string]interface{}) bool {
+	return n.left.eval(issue) && n.right.eval(issue)
+}
+func (n jqlOr) eval(issue map[string]interface{}) bool {
+	return n.left.eval(issue) || n.right.eval(issue)
+}
+func (n jqlNot) eval(issue map[string]interface{}) bool { return !n.inner.eval(issue) }
+
+// jqlFields maps accepted field names (and aliases) to their canonical name.
+var jqlFields = map[string]string{
+	"project": "project", "issuetype": "issuetype", "type": "issuetype",
+	"status": "status", "priority": "priority", "labels": "labels",
+	"assignee": "assignee", "reporter": "reporter", "resolution": "resolution",
+	"component": "component", "key": "key", "issuekey": "key", "summary": "summary",
+}
+
+func (n jqlClause) eval(issue map[string]interface{}) bool {
+	actual := jqlFieldValues(issue, n.field)
+	switch n.op {
+	case "is empty":
+		return len(actual) == 0
+	case "is not empty":
+		return len(actual) > 0
+	case "=", "in":
+		return jqlAnyEqual(actual, n.values)
+	case "!=", "not in":
+		// As in Jira, negative operators never match an empty field.
+		return len(actual) > 0 && !jqlAnyEqual(actual, n.values)
+	case "~":
+		return jqlAnyContains(actual, n.values[0])
+	case "!~":
+		return len(actual) > 0 && !jqlAnyContains(actual, n.values[0])
+	}
+	return false
+}
+
+func jqlAnyEqual(actual, want []string) bool {
+	for _, a := range actual {
+		for _, w := range want {
+			if strings.EqualFold(a, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func jqlAnyContains(actual []string, needle string) bool {
+	needle = strings.ToLower(needle)
+	for _, a := range actual {
+		if strings.Contains(strings.ToLower(a), needle) {
+			return true
+		}
+	}
+	return false
+}
+
+// jqlFieldValues extracts every value a clause may match for a field, e.g.
+// both the key and name of the project. An empty result means EMPTY.
+func jqlFieldValues(issue map[string]interface{}, field string) []string {
+	fields, _ := issue["fields"].(map[string]interface{})
+	named := func(v interface{}, keys ...string) []string {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		var out []string
+		for _, k := range keys {
+			if s, ok := obj[k].(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+
+	switch field {
+	case "project":
+		return named(fields["project"], "key", "name", "id")
+	case "issuetype":
+		return named(fields["issuetype"], "name", "id")
+	case "status":
+		return named(fields["status"], "name", "id")
+	case "priority":
+		return named(fields["priority"], "name", "id")
+	case "resolution":
+		return named(fields["resolution"], "name", "id")
+	case "assignee", "reporter":
+		return named(fields[field], "accountId", "displayName", "emailAddress")
+	case "labels":
+		var out []string
+		if labels, ok := fields["labels"].([]interface{}); ok {
+			for _, l := range labels {
+				if s, ok := l.(string); ok {
+					out = append(out, s)
+				}
+			}
+		}
+		return out
+	case "component":
+		var out []string
+		if comps, ok := fields["components"].([]interface{}); ok {
+			for _, c := range comps {
+				out = append(out, named(c, "name", "id")...)
+			}
+		}
+		return out
+	case "key":
+		return named(issue, "key", "id")
+	case "summary":
+		if s, ok := fields["summary"].(string); ok && s != "" {
+			return []string{s}
+		}
+	}
+	return nil
+}
+
+// ---- parser ----
+
+type jqlToken struct {
+	kind string // "word", "string", "op", "(", ")", ","
+	text string
+}
+
+func tokenizeJQL(src string) ([]jqlToken, error) {
+	var tokens []jqlToken
+	rs := []rune(src)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == ',':
+			tokens = append(tokens, jqlToken{kind: string(r), text: string(r)})
+			i++
+		case r == '"' || r == '\'':
+			j := i + 1
+			var sb strings.Builder
+			for j < len(rs) && rs[j] != r {
+				if rs[j] == '\\' && j+1 < len(rs) {
+					j++
+				}
+				sb.WriteRune(rs[j])
+				j++
+			}
+			if j >= len(rs) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, jqlToken{kind: "string", text: sb.String()})
+			i = j + 1
+		case r == '=' || r == '~':
+			tokens = append(tokens, jqlToken{kind: "op", text: string(r)})
+			i++
+		case r == '!':
+			if i+1 < len(rs) && (rs[i+1] == '=' || rs[i+1] == '~') {
+				tokens = append(tokens, jqlToken{kind: "op", text: string(rs[i : i+2])})
+				i += 2
+				continue
+			}
+			return nil, fmt.Errorf("unexpected '!'")
+		default:
+			j := i
+			for j < len(rs) && !unicode.IsSpace(rs[j]) && !strings.ContainsRune("()=,!~\"'", rs[j]) {
+				j++
+			}
+			if j == i {
+				return nil, fmt.Errorf("unexpected %q", r)
+			}
+			tokens = append(tokens, jqlToken{kind: "word", text: string(rs[i:j])})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+type jqlParser struct {
+	tokens []jqlToken
+	pos    int
+}
+
+// parseJQL compiles a JQL string into an evaluable tree.
+func parseJQL(src string) (jqlNode, error) {
+	tokens, err := tokenizeJQL(src)
+	if err != nil {
+		return nil, err
+	}
+	// Drop a trailing ORDER BY clause; ordering is meaningless for a filter.
+	for i := 0; i+1 < len(tokens); i++ {
+		if tokens[i].kind == "word" && strings.EqualFold(tokens[i].text, "order") &&
+			tokens[i+1].kind == "word" && strings.EqualFold(tokens[i+1].text, "by") {
+			tokens = tokens[:i]
+			break
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	p := &jqlParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	return node, nil
+}
+
+func (p *jqlParser) peek() (jqlToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return jqlToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *jqlParser) keyword(kw string) bool {
+	t, ok := p.peek()
+	if ok && t.kind == "word" && strings.EqualFold(t.text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *jqlParser) parseOr() (jqlNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = jqlOr{left, right}
+	}
+	return left, nil
+}
+
+func (p *jqlParser) parseAnd() (jqlNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = jqlAnd{left, right}
+	}
+	return left, nil
+}
+
+func (p *jqlParser) parseNot() (jqlNode, error) {
+	if p.keyword("not") {
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return jqlNot{inner}, nil
+	}
+	if t, ok := p.peek(); ok && t.kind == "(" {
+		p.pos++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t, ok := p.peek(); !ok || t.kind != ")" {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		p.pos++
+		return node, nil
+	}
+	return p.parseClause()
+}
+
+func (p *jqlParser) parseClause() (jqlNode, error) {
+	t, ok := p.peek()
+	if !ok || (t.kind != "word" && t.kind != "string") {
+		return nil, fmt.Errorf("expected a field name")
+	}
+	field, known := jqlFields[strings.ToLower(t.text)]
+	if !known {
+		return nil, fmt.Errorf("unsupported field %q", t.text)
+	}
+	p.pos++
+
+	clause := jqlClause{field: field}
+	switch {
+	case p.keyword("is"):
+		negate := p.keyword("not")
+		if !p.keyword("empty") && !p.keyword("null") {
+			return nil, fmt.Errorf("expected EMPTY after IS")
+		}
+		clause.op = "is empty"
+		if negate {
+			clause.op = "is not empty"
+		}
+		return clause, nil
+	case p.keyword("in"):
+		clause.op = "in"
+	case p.keyword("not"):
+		if !p.keyword("in") {
+			return nil, fmt.Errorf("expected IN after NOT")
+		}
+		clause.op = "not in"
+	default:
+		op, ok := p.peek()
+		if !ok || op.kind != "op" {
+			return nil, fmt.Errorf("expected an operator after %s", field)
+		}
+		p.pos++
+		clause.op = op.text
+	}
+
+	if clause.op == "in" || clause.op == "not in" {
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		clause.values = values
+		return clause, nil
+	}
+
+	v, ok := p.peek()
+	if !ok || (v.kind != "word" && v.kind != "string") {
+		return nil, fmt.Errorf("expected a value after %s %s", field, clause.op)
+	}
+	p.pos++
+	if v.kind == "word" && (strings.EqualFold(v.text, "empty") || strings.EqualFold(v.text, "null")) {
+		switch clause.op {
+		case "=":
+			clause.op = "is empty"
+			return clause, nil
+		case "!=":
+			clause.op = "is not empty"
+			return clause, nil
+		}
+	}
+	clause.values = []string{v.text}
+	return clause, nil
+}
+
+func (p *jqlParser) parseList() ([]string, error) {
+	if t, ok := p.peek(); !ok || t.kind != "(" {
+		return nil, fmt.Errorf("expected '(' after IN")
+	}
+	p.pos++
+	var values []string
+	for {
+		t, ok := p.peek()
+		if !ok || (t.kind != "word" && t.kind != "string") {
+			return nil, fmt.Errorf("expected a value in list")
+		}
+		values = append(values, t.text)
+		p.pos++
+		t, ok = p.peek()
+		if !ok {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		p.pos++
+		if t.kind == ")" {
+			return values, nil
+		}
+		if t.kind != "," {
+			return nil, fmt.Errorf("expected ',' or ')' in list")
+		}
+	}
+}
+
+// matchJQL evaluates a JQL filter against a Jira webhook payload. It returns
+// whether the issue matches and, if not, a reason suitable for logging.
+func matchJQL(jql string, payload map[string]interface{}) (bool, string) {
+	node, err := parseJQL(jql)
+	if err != nil {
+		return false, fmt.Sprintf("invalid JQL filter: %v", err)
+	}
+	issue, ok := payload["issue"].(map[string]interface{})
+	if !ok {
+		return false, "JQL filter set but payload has no issue"
+	}
+	if !node.eval(issue) {
+		return false, fmt.Sprintf("issue %v does not match JQL filter %q", issue["key"], jql)
+	}
+	return true, ""
+}
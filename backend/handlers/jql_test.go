@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"slices"
+	"testing"
+)
+
+// jqlIssue is the "issue" object of a Jira issue_updated webhook.
+var jqlIssue = map[string]interface{}{
+	"key": "WOP-42",
+	"id":  "10042",
+	"fields": map[string]interface{}{
+		"project":   map[string]interface{}{"key": "WOP", "name": "Workflow Platform", "id": "10000"},
+		"issuetype": map[string]interface{}{"name": "Bug", "id": "1"},
+		"status":    map[string]interface{}{"name": "In Progress", "id": "3"},
+		"priority":  map[string]interface{}{"name": "High", "id": "2"},
+		"labels":    []interface{}{"backend", "customer"},
+		"components": []interface{}{
+			map[string]interface{}{"name": "API", "id": "20"},
+			map[string]interface{}{"name": "Scheduler", "id": "21"},
+		},
+		"assignee":   map[string]interface{}{"accountId": "abc123", "displayName": "Sam Doe", "emailAddress": "sam@example.com"},
+		"reporter":   nil,
+		"resolution": nil,
+		"summary":    "Webhook outage in EU region",
+	},
+}
+
+func TestJQLFieldValues(t *testing.T) {
+	for field, want := range map[string][]string{
+		"project":    {"WOP", "Workflow Platform", "10000"},
+		"issuetype":  {"Bug", "1"},
+		"status":     {"In Progress", "3"},
+		"labels":     {"backend", "customer"},
+		"component":  {"API", "20", "Scheduler", "21"},
+		"assignee":   {"abc123", "Sam Doe", "sam@example.com"},
+		"key":        {"WOP-42", "10042"},
+		"summary":    {"Webhook outage in EU region"},
+		"reporter":   nil,
+		"resolution": nil,
+	} {
+		if got := jqlFieldValues(jqlIssue, field); !slices.Equal(got, want) {
+			t.Errorf("jqlFieldValues(%s) = %q, want %q", field, got, want)
+		}
+	}
+}
+
+func TestParseJQLEval(t *testing.T) {
+	matching := []string{
+		`project = WOP`,
+		`project = "Workflow Platform"`,
+		`project = wop`,
+		`PROJECT = WOP AnD Status = "in progress"`,
+		`type = Bug`,
+		`issuekey = 'wop-42'`,
+		`labels = customer`,
+		`component = Scheduler`,
+		`assignee = "sam@example.com"`,
+		`status != Done`,
+		`issuetype IN (Bug, Task)`,
+		`status NOT IN (Done, Closed)`,
+		`summary ~ "EU REGION"`,
+		`summary !~ resolved`,
+		`resolution IS EMPTY`,
+		`reporter is null`,
+		`resolution = EMPTY`,
+		`assignee IS NOT EMPTY`,
+		`project = WOP OR project = X AND status = Done`,
+		`NOT status = Done AND priority = High`,
+		`project = WOP ORDER BY created DESC`,
+	}
+	notMatching := []string{
+		`priority = Low`,
+		`labels != customer`,
+		`status not in ("In Progress")`,
+		`summary !~ webhook`,
+		`assignee IS EMPTY`,
+		// Negative operators never match an empty field.
+		`resolution != Fixed`,
+		`resolution NOT IN (Fixed)`,
+		`reporter !~ sam`,
+		`(project = WOP OR project = X) AND status = Done`,
+		`NOT (status = Done OR priority = High)`,
+	}
+	for _, set := range []struct {
+		queries []string
+		want    bool
+	}{{matching, true}, {notMatching, false}} {
+		for _, q := range set.queries {
+			node, err := parseJQL(q)
+			if err != nil {
+				t.Errorf("parseJQL(%q): %v", q, err)
+				continue
+			}
+			if got := node.eval(jqlIssue); got != set.want {
+				t.Errorf("%q matched = %v, want %v", q, got, set.want)
+			}
+		}
+	}
+}
+
+func TestParseJQLErrors(t *testing.T) {
+	for jql, want := range map[string]string{
+		``:                        "empty query",
+		`ORDER BY created`:        "empty query",
+		`sprint = 5`:              `unsupported field "sprint"`,
+		`status Done`:             "expected an operator after status",
+		`status =`:                "expected a value after status =",
+		`status ! Done`:           "unexpected '!'",
+		`summary ~ "outage`:       "unterminated string",
+		`status IS Done`:          "expected EMPTY after IS",
+		`status NOT Done`:         "expected IN after NOT",
+		`status IN Done`:          "expected '(' after IN",
+		`status IN ()`:            "expected a value in list",
+		`status IN (Done`:         "missing ')'",
+		`status IN (Done Closed)`: "expected ',' or ')' in list",
+		`(status = Done`:          "missing ')'",
+		`status = Done Closed`:    `unexpected "Closed"`,
+		`status = Done AND`:       "expected a field name",
+	} {
+		if _, err := parseJQL(jql); err == nil || err.Error() != want {
+			t.Errorf("parseJQL(%q) error = %v, want %q", jql, err, want)
+		}
+	}
+}
+
+func TestMatchJQL(t *testing.T) {
+	payload := map[string]interface{}{"webhookEvent": "jira:issue_updated", "issue": jqlIssue}
+
+	if ok, reason := matchJQL(`project = WOP AND labels = backend`, payload); !ok || reason != "" {
+		t.Errorf("matching filter = %v, %q", ok, reason)
+	}
+	if _, reason := matchJQL(`status = Done`, payload); reason != `issue WOP-42 does not match JQL filter "status = Done"` {
+		t.Errorf("non-matching filter reason = %q", reason)
+	}
+	if _, reason := matchJQL(`status = Done`, map[string]interface{}{"webhookEvent": "comment_created"}); reason != "JQL filter set but payload has no issue" {
+		t.Errorf("payload without issue reason = %q", reason)
+	}
+	if _, reason := matchJQL(`sprint = 5`, payload); reason != `invalid JQL filter: unsupported field "sprint"` {
+		t.Errorf("invalid filter reason = %q", reason)
+	}
+}
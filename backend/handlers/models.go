@@ -32,15 +32,85 @@ type Environment struct {
 	UpdatedAt time.Time         `json:"updated_at"`
 }
 
+type WorkflowTrigger struct {
+	ID           string          `json:"id"`
+	WorkflowID   string          `json:"workflow_id"`
+	Type         string          `json:"type"`
+	Name         string          `json:"name"`
+	CronSchedule *string         `json:"cron_schedule"`
+	NodeID       *string         `json:"node_id"`
+	Input        json.RawMessage `json:"input"`
+	Enabled      bool            `json:"enabled"`
+	Paused       bool            `json:"paused"`
+	CalendarID   *string         `json:"calendar_id"`
+	WebhookToken *string         `json:"webhook_token"`
+	WebhookPath  string          `json:"webhook_path,omitempty"`
+	LastFiredAt  *time.Time      `json:"last_fired_at"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+type ScheduleCalendar struct {
+	ID        string           `json:"id"`
+	Name      string           `json:"name"`
+	Timezone  string           `json:"timezone"`
+	Windows   []BlackoutWindow `json:"windows"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// BlackoutWindow is one period during which scheduled runs are skipped.
+// Type "dates" covers whole days Start..End (YYYY-MM-DD, End optional),
+// "weekly" covers Start..End (HH:MM) on each of Days, wrapping past midnight
+// when End <= Start, and "range" covers Start..End (YYYY-MM-DDTHH:MM).
+type BlackoutWindow struct {
+	Type  string   `json:"type"`
+	Label string   `json:"label"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+	Days  []string `json:"days,omitempty"`
+}
+
+type ScheduleSkip struct {
+	ID         string    `json:"id"`
+	TriggerID  string    `json:"trigger_id"`
+	WorkflowID string    `json:"workflow_id"`
+	FireTime   time.Time `json:"fire_time"`
+	Reason     string    `json:"reason"`
+	Detail     string    `json:"detail"`
+	CalendarID *string   `json:"calendar_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
 type WorkflowRun struct {
-	ID         string          `json:"id"`
-	WorkflowID string          `json:"workflow_id"`
-	Status     string          `json:"status"`
-	Input      json.RawMessage `json:"input"`
-	Output     json.RawMessage `json:"output"`
-	Message    string          `json:"message"`
-	StartedAt  time.Time       `json:"started_at"`
-	FinishedAt *time.Time      `json:"finished_at"`
+	ID             string          `json:"id"`
+	WorkflowID     string          `json:"workflow_id"`
+	TriggerID      *string         `json:"trigger_id"`
+	BackfillID     *string         `json:"backfill_id"`
+	ScheduledFor   *time.Time      `json:"scheduled_for"`
+	ReplayOf       *string         `json:"replay_of_event_id"`
+	Status         string          `json:"status"`
+	Input          json.RawMessage `json:"input"`
+	TriggerPayload json.RawMessage `json:"trigger_payload,omitempty"`
+	Output         json.RawMessage `json:"output"`
+	Message        string          `json:"message"`
+	StartedAt      time.Time       `json:"started_at"`
+	FinishedAt     *time.Time      `json:"finished_at"`
+}
+
+type Backfill struct {
+	ID             string         `json:"id"`
+	WorkflowID     string         `json:"workflow_id"`
+	TriggerID      *string        `json:"trigger_id"`
+	CronSchedule   string         `json:"cron_schedule"`
+	StartTime      time.Time      `json:"start_time"`
+	EndTime        time.Time      `json:"end_time"`
+	MaxConcurrency int            `json:"max_concurrency"`
+	TotalRuns      int            `json:"total_runs"`
+	Status         string         `json:"status"`
+	RunCounts      map[string]int `json:"run_counts,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	FinishedAt     *time.Time     `json:"finished_at"`
 }
 
 type WorkflowLog struct {
@@ -57,12 +127,49 @@ type WorkflowLog struct {
 }
 
 type Integration struct {
-	ID        string          `json:"id"`
-	Type      string          `json:"type"`
-	Name      string          `json:"name"`
-	Config    json.RawMessage `json:"config"`
-	CreatedAt time.Time       `json:"created_at"`
-	UpdatedAt time.Time       `json:"updated_at"`
+	ID              string          `json:"id"`
+	Type            string          `json:"type"`
+	Name            string          `json:"name"`
+	IsDefault       bool            `json:"is_default"`
+	Config          json.RawMessage `json:"config"`
+	SecretFields    []string        `json:"secret_fields"`
+	HealthStatus    string          `json:"health_status"`
+	HealthMessage   string          `json:"health_message"`
+	HealthLatencyMS *int64          `json:"health_latency_ms"`
+	HealthCheckedAt *time.Time      `json:"health_checked_at"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+// SecretAuditEntry records a request to reveal integration secrets.
+type SecretAuditEntry struct {
+	ID              int64           `json:"id"`
+	IntegrationID   *string         `json:"integration_id"`
+	IntegrationType string          `json:"integration_type"`
+	Fields          json.RawMessage `json:"fields"`
+	Actor           string          `json:"actor"`
+	Reason          string          `json:"reason"`
+	ClientIP        string          `json:"client_ip"`
+	Outcome         string          `json:"outcome"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+// JiraWebhook is a webhook this platform registered on Jira.
+type JiraWebhook struct {
+	ID               string          `json:"id"`
+	JiraWebhookID    string          `json:"jira_webhook_id"`
+	ConnectionID     string          `json:"connection_id"`
+	Name             string          `json:"name"`
+	URL              string          `json:"url"`
+	Events           json.RawMessage `json:"events"`
+	JQLFilter        string          `json:"jql_filter"`
+	WorkflowID       *string         `json:"workflow_id"`
+	Signed           bool            `json:"signed"`
+	Status           string          `json:"status"`
+	Drift            json.RawMessage `json:"drift,omitempty"`
+	LastReconciledAt *time.Time      `json:"last_reconciled_at"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
 }
 
 type WebhookEvent struct {
@@ -72,9 +179,41 @@ type WebhookEvent struct {
 	Payload       json.RawMessage `json:"payload"`
 	Processed     bool            `json:"processed"`
 	WorkflowRunID *string         `json:"workflow_run_id"`
+	Runs          []EventRun      `json:"runs"`
+	Verification  string          `json:"verification"`
+	Quarantined   bool            `json:"quarantined"`
+	MatchResults  json.RawMessage `json:"match_results,omitempty"`
+	SkipReason    *string         `json:"skip_reason,omitempty"`
 	CreatedAt     time.Time       `json:"created_at"`
 }
 
+// EventRun is one run started by a webhook event.
+type EventRun struct {
+	RunID      string    `json:"run_id"`
+	WorkflowID string    `json:"workflow_id"`
+	TriggerID  *string   `json:"trigger_id"`
+	Status     string    `json:"status"`
+	Replay     bool      `json:"replay"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TriggerMatch records how one trigger node evaluated an inbound event.
+type TriggerMatch struct {
+	WorkflowID string        `json:"workflow_id"`
+	NodeID     string        `json:"node_id"`
+	Matched    bool          `json:"matched"`
+	Reason     string        `json:"reason,omitempty"`
+	RunID      string        `json:"run_id,omitempty"`
+	Filter     *FilterResult `json:"filter,omitempty"`
+}
+
+// FilterResult is how a trigger node's filter_expression evaluated an event.
+type FilterResult struct {
+	Expression string `json:"expression"`
+	Passed     bool   `json:"passed"`
+	Reason     string `json:"reason,omitempty"`
+}
+
 type NodeSchema struct {
 	Type          string          `json:"type"`
 	Label         string          `json:"label"`
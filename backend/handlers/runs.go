@@ -6,13 +6,12 @@ import (
 	"log"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 // ==================== Run Handlers ====================
 
 func (h *Handler) getRuns(c *gin.Context) {
-	rows, err := h.db.Query("SELECT id, workflow_id, status, input, output, COALESCE(message, '') as message, started_at, finished_at FROM workflow_runs ORDER BY started_at DESC")
+	rows, err := h.db.Query("SELECT id, workflow_id, trigger_id, backfill_id, scheduled_for, replay_of_event_id, status, input, output, COALESCE(message, '') as message, started_at, finished_at FROM workflow_runs ORDER BY started_at DESC")
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
@@ -23,7 +22,7 @@ func (h *Handler) getRuns(c *gin.Context) {
 	for rows.Next() {
 		var r WorkflowRun
 		var input, output sql.NullString
-		if err := rows.Scan(&r.ID, &r.WorkflowID, &r.Status, &input, &output, &r.Message, &r.StartedAt, &r.FinishedAt); err != nil {
+		if err := rows.Scan(&r.ID, &r.WorkflowID, &r.TriggerID, &r.BackfillID, &r.ScheduledFor, &r.ReplayOf, &r.Status, &input, &output, &r.Message, &r.StartedAt, &r.FinishedAt); err != nil {
 			log.Printf("Failed to scan run row: %v", err)
 			continue
 		}
@@ -41,9 +40,9 @@ func (h *Handler) getRuns(c *gin.Context) {
 func (h *Handler) getRun(c *gin.Context) {
 	id := c.Param("id")
 	var r WorkflowRun
-	var input, output, message sql.NullString
-	err := h.db.QueryRow("SELECT id, workflow_id, status, input, output, COALESCE(message, '') as message, started_at, finished_at FROM workflow_runs WHERE id = ?", id).
-		Scan(&r.ID, &r.WorkflowID, &r.Status, &input, &output, &message, &r.StartedAt, &r.FinishedAt)
+	var input, triggerPayload, output, message sql.NullString
+	err := h.db.QueryRow("SELECT id, workflow_id, trigger_id, backfill_id, scheduled_for, replay_of_event_id, status, input, trigger_payload, output, COALESCE(message, '') as message, started_at, finished_at FROM workflow_runs WHERE id = ?", id).
+		Scan(&r.ID, &r.WorkflowID, &r.TriggerID, &r.BackfillID, &r.ScheduledFor, &r.ReplayOf, &r.Status, &input, &triggerPayload, &output, &message, &r.StartedAt, &r.FinishedAt)
 	if err == sql.ErrNoRows {
 		c.JSON(404, gin.H{"error": "Run not found"})
 		return
@@ -55,6 +54,9 @@ func (h *Handler) getRun(c *gin.Context) {
 	if input.Valid {
 		r.Input = json.RawMessage(input.String)
 	}
+	if triggerPayload.Valid {
+		r.TriggerPayload = json.RawMessage(triggerPayload.String)
+	}
 	if output.Valid {
 		r.Output = json.RawMessage(output.String)
 	}
@@ -108,25 +110,19 @@ func (h *Handler) runWorkflow(c *gin.Context) {
 	}
 	c.ShouldBindJSON(&req)
 
-	runID := uuid.New().String()
-	_, err := h.db.Exec(
-		"INSERT INTO workflow_runs (id, workflow_id, status, input) VALUES (?, ?, 'running', ?)",
-		runID, workflowID, req.Input,
-	)
-	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
-		return
-	}
-
 	var w Workflow
-	err = h.db.QueryRow("SELECT id, name, nodes, edges FROM workflows WHERE id = ?", workflowID).
+	err := h.db.QueryRow("SELECT id, name, nodes, edges FROM workflows WHERE id = ?", workflowID).
 		Scan(&w.ID, &w.Name, &w.Nodes, &w.Edges)
 	if err != nil {
 		c.JSON(404, gin.H{"error": "Workflow not found"})
 		return
 	}
 
-	go h.executeWorkflow(runID, w, req.Input)
+	runID, err := h.startRun(w, req.Input, runOptions{})
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
 
 	c.JSON(200, gin.H{
 		"run_id":  runID,
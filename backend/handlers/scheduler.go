@@ -1,135 +1,355 @@
 package handlers
 
 import (
-	"encoding/json"
+	"container/heap"
 	"fmt"
 	"log"
-	"strings"
+	"sort"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/gin-gonic/gin"
 )
 
 // ==================== Cron Scheduler ====================
 
-// StartCronScheduler runs every 60 seconds and triggers scheduled workflows.
-func (h *Handler) StartCronScheduler() {
-	log.Println("🕐 Cron scheduler started")
-	ticker := time.NewTicker(60 * time.Second)
-	defer ticker.Stop()
+// scheduleEntry is one schedule trigger waiting in the timer heap.
+type scheduleEntry struct {
+	triggerID  string
+	workflowID string
+	expr       string
+	sched      cronSchedule
+	next       time.Time
+	index      int
+}
+
+// scheduleHeap orders entries by next fire time (container/heap).
+type scheduleHeap []*scheduleEntry
+
+func (q scheduleHeap) Len() int           { return len(q) }
+func (q scheduleHeap) Less(i, j int) bool { return q[i].next.Before(q[j].next) }
+func (q scheduleHeap) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+func (q *scheduleHeap) Push(x interface{}) {
+	e := x.(*scheduleEntry)
+	e.index = len(*q)
+	*q = append(*q, e)
+}
+func (q *scheduleHeap) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	e.index = -1
+	*q = old[:len(old)-1]
+	return e
+}
+
+// schedulerMetrics tracks how late scheduled runs start relative to their
+// intended fire time.
+type schedulerMetrics struct {
+	Fired       int64
+	LastLag     time.Duration
+	MaxLag      time.Duration
+	TotalLag    time.Duration
+	LastFiredAt *time.Time
+}
 
-	for range ticker.C {
-		h.checkAndRunCronWorkflows()
+// cronScheduler keeps the next fire time of every enabled schedule trigger
+// in a min-heap and sleeps until the earliest one is due.
+type cronScheduler struct {
+	mu      sync.Mutex
+	entries map[string]*scheduleEntry
+	queue   scheduleHeap
+	wake    chan struct{}
+	paused  bool
+	metrics schedulerMetrics
+}
+
+func newCronScheduler() *cronScheduler {
+	return &cronScheduler{
+		entries: map[string]*scheduleEntry{},
+		wake:    make(chan struct{}, 1),
 	}
 }
 
-func (h *Handler) checkAndRunCronWorkflows() {
-	rows, err := h.db.Query(
-		"SELECT id, name, nodes, edges, cron_schedule, last_cron_run FROM workflows WHERE status = 'active' AND trigger_type = 'schedule' AND cron_schedule IS NOT NULL",
-	)
-	if err != nil {
-		log.Printf("Cron scheduler query failed: %v", err)
-		return
+// notify wakes the scheduler loop so it re-reads the head of the heap.
+func (s *cronScheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
 	}
-	defer rows.Close()
+}
 
-	now := time.Now()
+// setPaused toggles the global pause. Paused schedules keep ticking so each
+// skipped tick is recorded.
+func (s *cronScheduler) setPaused(paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = paused
+}
 
-	for rows.Next() {
-		var w Workflow
-		if err := rows.Scan(&w.ID, &w.Name, &w.Nodes, &w.Edges, &w.CronSchedule, &w.LastCronRun); err != nil {
-			continue
-		}
+func (s *cronScheduler) isPaused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused
+}
 
-		if w.CronSchedule == nil {
-			continue
+// upsert adds or replaces the entry for a trigger. lastRun anchors @every
+// schedules so a restart does not reset their interval.
+func (s *cronScheduler) upsert(triggerID, workflowID, expr string, lastRun *time.Time) error {
+	sched, err := parseCronSchedule(expr)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	next := sched.next(now)
+	if _, ok := sched.(everySchedule); ok && lastRun != nil {
+		next = sched.next(*lastRun)
+		if next.Before(now) {
+			next = now
 		}
+	}
+	if next.IsZero() {
+		return fmt.Errorf("schedule %q never fires", expr)
+	}
 
-		if shouldRunCron(*w.CronSchedule, w.LastCronRun, now) {
-			log.Printf("🕐 Cron triggering workflow '%s' (id=%s, schedule=%s)", w.Name, w.ID, *w.CronSchedule)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[triggerID]; ok {
+		if e.expr == expr {
+			return nil
+		}
+		heap.Remove(&s.queue, e.index)
+	}
+	e := &scheduleEntry{triggerID: triggerID, workflowID: workflowID, expr: expr, sched: sched, next: next}
+	s.entries[triggerID] = e
+	heap.Push(&s.queue, e)
+	s.notify()
+	return nil
+}
 
-			runID := uuid.New().String()
-			h.db.Exec(
-				"INSERT INTO workflow_runs (id, workflow_id, status, input) VALUES (?, ?, 'running', '{}')",
-				runID, w.ID,
-			)
-			h.db.Exec("UPDATE workflows SET last_cron_run = ? WHERE id = ?", now, w.ID)
+// remove drops a trigger from the heap if present.
+func (s *cronScheduler) remove(triggerID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[triggerID]; ok {
+		heap.Remove(&s.queue, e.index)
+		delete(s.entries, triggerID)
+		s.notify()
+	}
+}
 
-			go h.executeWorkflow(runID, w, json.RawMessage(`{}`))
+// removeWorkflow drops every trigger of a workflow except those in keep.
+func (s *cronScheduler) removeWorkflow(workflowID string, keep map[string]bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, e := range s.entries {
+		if e.workflowID == workflowID && !keep[id] {
+			heap.Remove(&s.queue, e.index)
+			delete(s.entries, id)
 		}
 	}
+	s.notify()
 }
 
-// shouldRunCron determines if a cron workflow should run based on its schedule.
-func shouldRunCron(schedule string, lastRun *time.Time, now time.Time) bool {
-	schedule = strings.TrimSpace(schedule)
+// popDue removes and returns all entries due at or before now, rescheduling
+// each at its following fire time.
+func (s *cronScheduler) popDue(now time.Time) []scheduleEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	if strings.HasPrefix(schedule, "@every ") {
-		durationStr := strings.TrimPrefix(schedule, "@every ")
-		d, err := time.ParseDuration(durationStr)
-		if err != nil {
-			return false
+	var due []scheduleEntry
+	for len(s.queue) > 0 && !s.queue[0].next.After(now) {
+		e := s.queue[0]
+		due = append(due, *e)
+
+		lag := now.Sub(e.next)
+		s.metrics.Fired++
+		s.metrics.LastLag = lag
+		s.metrics.TotalLag += lag
+		if lag > s.metrics.MaxLag {
+			s.metrics.MaxLag = lag
 		}
-		if lastRun == nil {
-			return true
+		firedAt := now
+		s.metrics.LastFiredAt = &firedAt
+
+		// Skip ticks missed while the process was busy rather than bursting.
+		next := e.sched.next(e.next)
+		if !next.IsZero() && !next.After(now) {
+			next = e.sched.next(now)
 		}
-		return now.Sub(*lastRun) >= d
-	}
-
-	var interval time.Duration
-	switch schedule {
-	case "@hourly":
-		interval = time.Hour
-	case "@daily":
-		interval = 24 * time.Hour
-	case "@weekly":
-		interval = 7 * 24 * time.Hour
-	case "@monthly":
-		interval = 30 * 24 * time.Hour
-	default:
-		return shouldRunStandardCron(schedule, lastRun, now)
+		if next.IsZero() {
+			heap.Pop(&s.queue)
+			delete(s.entries, e.triggerID)
+			continue
+		}
+		e.next = next
+		heap.Fix(&s.queue, e.index)
 	}
+	return due
+}
 
-	if lastRun == nil {
-		return true
+// untilNext returns how long to sleep before the earliest entry is due.
+func (s *cronScheduler) untilNext() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) == 0 {
+		return time.Hour
 	}
-	return now.Sub(*lastRun) >= interval
+	return time.Until(s.queue[0].next)
 }
 
-// shouldRunStandardCron handles standard 5-field cron: min hour dom month dow.
-func shouldRunStandardCron(schedule string, lastRun *time.Time, now time.Time) bool {
-	fields := strings.Fields(schedule)
-	if len(fields) != 5 {
-		return false
+// StartCronScheduler loads all schedule triggers and fires each one at its
+// exact next fire time. It blocks forever; run it in a goroutine.
+func (h *Handler) StartCronScheduler() {
+	h.loadSchedulerPaused()
+	h.loadAllSchedules()
+	log.Println("🕐 Cron scheduler started")
+
+	for {
+		timer := time.NewTimer(h.scheduler.untilNext())
+		select {
+		case <-timer.C:
+			for _, e := range h.scheduler.popDue(time.Now()) {
+				h.fireSchedule(e)
+			}
+		case <-h.scheduler.wake:
+			timer.Stop()
+		}
 	}
+}
+
+// scheduleTriggerQuery selects enabled schedule triggers of active workflows.
+const scheduleTriggerQuery = `SELECT t.id, t.workflow_id, t.cron_schedule, t.last_fired_at
+	FROM workflow_triggers t JOIN workflows w ON w.id = t.workflow_id
+	WHERE t.type = 'schedule' AND t.enabled = TRUE AND t.cron_schedule IS NOT NULL AND w.status = 'active'`
 
-	if !cronFieldMatches(fields[0], now.Minute()) {
-		return false
+// loadSchedules upserts every trigger returned by the query into the heap
+// and returns the IDs it loaded.
+func (h *Handler) loadSchedules(query string, args ...interface{}) map[string]bool {
+	loaded := map[string]bool{}
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		log.Printf("Cron scheduler query failed: %v", err)
+		return loaded
 	}
-	if !cronFieldMatches(fields[1], now.Hour()) {
-		return false
+	defer rows.Close()
+
+	for rows.Next() {
+		var triggerID, workflowID, expr string
+		var lastFired *time.Time
+		if err := rows.Scan(&triggerID, &workflowID, &expr, &lastFired); err != nil {
+			continue
+		}
+		if err := h.scheduler.upsert(triggerID, workflowID, expr, lastFired); err != nil {
+			log.Printf("⚠️ Ignoring invalid cron schedule %q on trigger %s: %v", expr, triggerID, err)
+			continue
+		}
+		loaded[triggerID] = true
 	}
+	return loaded
+}
+
+// loadAllSchedules seeds the heap from the database.
+func (h *Handler) loadAllSchedules() {
+	h.loadSchedules(scheduleTriggerQuery)
+}
 
-	if lastRun != nil && lastRun.Truncate(time.Minute).Equal(now.Truncate(time.Minute)) {
-		return false
+// refreshWorkflowSchedule re-reads a workflow's schedule triggers and updates
+// the heap. Call it whenever a workflow's status or triggers change.
+func (h *Handler) refreshWorkflowSchedule(workflowID string) {
+	loaded := h.loadSchedules(scheduleTriggerQuery+" AND t.workflow_id = ?", workflowID)
+	h.scheduler.removeWorkflow(workflowID, loaded)
+}
+
+// fireSchedule starts a run for a due schedule entry.
+func (h *Handler) fireSchedule(e scheduleEntry) {
+	var w Workflow
+	err := h.db.QueryRow("SELECT id, name, nodes, edges FROM workflows WHERE id = ? AND status = 'active'", e.workflowID).
+		Scan(&w.ID, &w.Name, &w.Nodes, &w.Edges)
+	if err != nil {
+		log.Printf("Cron scheduler could not load workflow %s: %v", e.workflowID, err)
+		h.scheduler.remove(e.triggerID)
+		return
+	}
+	t, err := h.fetchTrigger(e.workflowID, e.triggerID)
+	if err != nil || !t.Enabled {
+		h.scheduler.remove(e.triggerID)
+		return
+	}
+	if reason, detail, calendarID := h.scheduleSkipReason(t, e.next); reason != "" {
+		h.recordScheduleSkip(t, e.next, reason, detail, calendarID)
+		return
 	}
 
-	return true
+	now := time.Now()
+	lag := now.Sub(e.next)
+	log.Printf("🕐 Cron triggering workflow '%s' (id=%s, trigger=%s, schedule=%s, lag=%v)", w.Name, w.ID, t.ID, e.expr, lag)
+
+	schedule := scheduleMetadata(e.expr, e.next)
+	schedule["trigger_id"] = t.ID
+	schedule["started_at"] = now.Format(time.RFC3339)
+	schedule["previous_fire_time"] = h.previousSuccessfulFireTime(t.ID, e.next)
+	input := withScheduleInput(t.Input, schedule)
+
+	fireTime := e.next
+	if _, err := h.startRun(w, input, runOptions{TriggerID: &t.ID, ScheduledFor: &fireTime, StartNodeID: triggerNodeID(t)}); err != nil {
+		log.Printf("Cron scheduler failed to start run for workflow %s: %v", w.ID, err)
+		return
+	}
+	h.markTriggerFired(t.ID)
+	h.db.Exec("UPDATE workflows SET last_cron_run = ? WHERE id = ?", now, w.ID)
 }
 
-// cronFieldMatches checks if a cron field matches a value.
-// Supports: * (any), exact number, */interval.
-func cronFieldMatches(field string, value int) bool {
-	if field == "*" {
-		return true
+// previousSuccessfulFireTime returns the fire time of the trigger's most
+// recent successful scheduled run before the given tick, or nil if none.
+func (h *Handler) previousSuccessfulFireTime(triggerID string, before time.Time) interface{} {
+	var prev *time.Time
+	h.db.QueryRow(
+		"SELECT MAX(scheduled_for) FROM workflow_runs WHERE trigger_id = ? AND status = 'success' AND scheduled_for < ?",
+		triggerID, before,
+	).Scan(&prev)
+	if prev == nil {
+		return nil
 	}
-	var exact int
-	if _, err := fmt.Sscanf(field, "%d", &exact); err == nil {
-		return exact == value
+	return prev.Format(time.RFC3339)
+}
+
+// getSchedulerMetrics reports scheduling lag and the upcoming fire times.
+func (h *Handler) getSchedulerMetrics(c *gin.Context) {
+	s := h.scheduler
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var avgLag time.Duration
+	if s.metrics.Fired > 0 {
+		avgLag = s.metrics.TotalLag / time.Duration(s.metrics.Fired)
 	}
-	var interval int
-	if _, err := fmt.Sscanf(field, "*/%d", &interval); err == nil && interval > 0 {
-		return value%interval == 0
+
+	queue := append(scheduleHeap(nil), s.queue...)
+	sort.Slice(queue, func(i, j int) bool { return queue[i].next.Before(queue[j].next) })
+
+	upcoming := make([]gin.H, 0, len(queue))
+	for _, e := range queue {
+		upcoming = append(upcoming, gin.H{
+			"trigger_id":    e.triggerID,
+			"workflow_id":   e.workflowID,
+			"cron_schedule": e.expr,
+			"next_fire_at":  e.next,
+		})
 	}
-	return false
+
+	c.JSON(200, gin.H{
+		"schedules":     len(s.entries),
+		"paused":        s.paused,
+		"fired":         s.metrics.Fired,
+		"last_fired_at": s.metrics.LastFiredAt,
+		"last_lag_ms":   s.metrics.LastLag.Milliseconds(),
+		"max_lag_ms":    s.metrics.MaxLag.Milliseconds(),
+		"avg_lag_ms":    avgLag.Milliseconds(),
+		"upcoming":      upcoming,
+	})
 }
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// slackSignatureMaxAge bounds X-Slack-Request-Timestamp to stop replays.
+const slackSignatureMaxAge = 5 * time.Minute
+
+// ==================== Webhook Signatures ====================
+
+// Verification outcomes stored on webhook_events.verification.
+const (
+	VerificationVerified      = "verified"
+	VerificationUnsigned      = "unsigned"
+	VerificationInvalid       = "invalid"
+	VerificationNotConfigured = "not_configured"
+)
+
+// hmacSHA256Hex returns the hex HMAC-SHA256 of body keyed by secret.
+func hmacSHA256Hex(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyPrefixedHMAC checks a header of the form "sha256=<hex>" against the
+// HMAC-SHA256 of body.
+func verifyPrefixedHMAC(secret string, body []byte, header string) bool {
+	sig, ok := strings.CutPrefix(strings.TrimSpace(header), "sha256=")
+	if !ok || sig == "" {
+		return false
+	}
+	return hmac.Equal([]byte(strings.ToLower(sig)), []byte(hmacSHA256Hex(secret, body)))
+}
+
+// verifySlackSignature checks X-Slack-Signature ("v0=<hex>"), the HMAC of
+// "v0:<timestamp>:<body>", and that the timestamp is recent.
+func verifySlackSignature(secret string, body []byte, timestamp, signature string) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := time.Since(time.Unix(ts, 0))
+	if age > slackSignatureMaxAge || age < -slackSignatureMaxAge {
+		return false
+	}
+	sig, ok := strings.CutPrefix(strings.TrimSpace(signature), "v0=")
+	if !ok || sig == "" {
+		return false
+	}
+	base := append([]byte("v0:"+timestamp+":"), body...)
+	return hmac.Equal([]byte(strings.ToLower(sig)), []byte(hmacSHA256Hex(secret, base)))
+}
+
+// constantTimeEqual compares two secrets without leaking timing.
+func constantTimeEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
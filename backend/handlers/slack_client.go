@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==================== Slack API Client ====================
+
+const slackAPIBase = "https://slack.com/api/"
+
+// slackChannelCacheTTL is how long resolved channel names are remembered.
+const slackChannelCacheTTL = 10 * time.Minute
+
+// slackIDPattern matches conversation IDs (C…, G…, D…), which need no lookup.
+var slackIDPattern = regexp.MustCompile(`^[CGD][A-Z0-9]{8,}$`)
+
+// slackClient calls the Slack Web API with a bot or OAuth token.
+type slackClient struct {
+	token  string
+	client *http.Client
+}
+
+// slackAPIError is returned when Slack answers {"ok": false}; Body is the
+// full response.
+type slackAPIError struct {
+	Method string
+	Code   string
+	Body   []byte
+}
+
+func (e *slackAPIError) Error() string {
+	return fmt.Sprintf("Slack API error: %s (%s)", e.Code, e.Method)
+}
+
+func newSlackClient(token string) *slackClient {
+	return &slackClient{token: token, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// slackClientForConnection builds a client from a slack connection, or from
+// the default one when connectionID is empty. The error text is shown to
+// users as-is.
+func (h *Handler) slackClientForConnection(connectionID string) (*slackClient, error) {
+	config, err := h.loadConnectionConfig("slack", connectionID)
+	if err != nil {
+		return nil, errors.New(connectionError("Slack", connectionID, err))
+	}
+	token := connectionToken(config, "bot_token")
+	if token == "" {
+		return nil, errors.New("Slack integration config incomplete: need bot_token")
+	}
+	return newSlackClient(token), nil
+}
+
+// call sends a JSON request to a Web API method and decodes the response
+// into out when non-nil. A response with "ok": false is returned as
+// *slackAPIError along with the body.
+func (sc *slackClient) call(method string, payload, out interface{}) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", slackAPIBase+method, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create Slack request: %v", err)
+	}
+	req.Header.Set(ContentTypeHeader, ContentTypeJSON+"; charset=utf-8")
+	return sc.send(method, req, out)
+}
+
+// callForm sends a form-encoded request, for methods that do not read JSON
+// bodies (users.lookupByEmail, conversations.list, files.getUploadURLExternal).
+func (sc *slackClient) callForm(method string, form url.Values, out interface{}) ([]byte, error) {
+	req, err := http.NewRequest("POST", slackAPIBase+method, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create Slack request: %v", err)
+	}
+	req.Header.Set(ContentTypeHeader, "application/x-www-form-urlencoded")
+	return sc.send(method, req, out)
+}
+
+func (sc *slackClient) send(method string, req *http.Request, out interface{}) ([]byte, error) {
+	req.Header.Set(AuthorizationHeader, "Bearer "+sc.token)
+	resp, err := sc.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Slack API call failed: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+
+	var status struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(respBody, &status); err != nil {
+		return respBody, fmt.Errorf("Slack API returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if !status.OK {
+		return respBody, &slackAPIError{Method: method, Code: status.Error, Body: respBody}
+	}
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return respBody, fmt.Errorf("Unexpected Slack response: %v", err)
+		}
+	}
+	return respBody, nil
+}
+
+// slackUser is the part of a Slack user object the nodes output.
+type slackUser struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	RealName string `json:"real_name"`
+	TZ       string `json:"tz"`
+	IsBot    bool   `json:"is_bot"`
+	Profile  struct {
+		Email       string `json:"email"`
+		DisplayName string `json:"display_name"`
+	} `json:"profile"`
+}
+
+// lookupUserByEmail finds a workspace member by email (users:read.email).
+func (sc *slackClient) lookupUserByEmail(email string) (slackUser, error) {
+	var res struct {
+		User slackUser `json:"user"`
+	}
+	_, err := sc.callForm("users.lookupByEmail", url.Values{"email": {email}}, &res)
+	return res.User, err
+}
+
+// resolveUser accepts a user ID or an email address.
+func (sc *slackClient) resolveUser(user string) (string, error) {
+	if !strings.Contains(user, "@") {
+		return strings.TrimPrefix(user, "@"), nil
+	}
+	u, err := sc.lookupUserByEmail(user)
+	if err != nil {
+		return "", fmt.Errorf("no Slack user with email %s: %v", user, err)
+	}
+	return u.ID, nil
+}
+
+var (
+	slackChannelCache   = map[string]slackChannelEntry{}
+	slackChannelCacheMu sync.Mutex
+)
+
+type slackChannelEntry struct {
+	id      string
+	expires time.Time
+}
+
+// resolveChannel turns a channel reference into a conversation ID:
+//
+//	C01ABCD1234        used as-is
+//	#general, general  looked up by name
+//	user@example.com   direct message with the user of that email
+//	@U01ABCD1234       direct message with that user
+func (sc *slackClient) resolveChannel(channel string) (string, error) {
+	channel = strings.TrimSpace(channel)
+	if channel == "" || slackIDPattern.MatchString(channel) {
+		return channel, nil
+	}
+	if strings.Contains(channel, "@") {
+		userID, err := sc.resolveUser(channel)
+		if err != nil {
+			return "", err
+		}
+		var res struct {
+			Channel struct {
+				ID string `json:"id"`
+			} `json:"channel"`
+		}
+		if _, err := sc.call("conversations.open", map[string]interface{}{"users": userID}, &res); err != nil {
+			return "", fmt.Errorf("failed to open a DM with %s: %v", channel, err)
+		}
+		return res.Channel.ID, nil
+	}
+
+	name := strings.TrimPrefix(channel, "#")
+	cacheKey := sc.token + "\x00" + name
+	slackChannelCacheMu.Lock()
+	entry, ok := slackChannelCache[cacheKey]
+	slackChannelCacheMu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.id, nil
+	}
+
+	id, err := sc.findChannelID(name)
+	if err != nil {
+		return "", err
+	}
+	slackChannelCacheMu.Lock()
+	slackChannelCache[cacheKey] = slackChannelEntry{id: id, expires: time.Now().Add(slackChannelCacheTTL)}
+	slackChannelCacheMu.Unlock()
+	return id, nil
+}
+
+// findChannelID pages through the conversations the token can see.
+func (sc *slackClient) findChannelID(name string) (string, error) {
+	cursor := ""
+	for {
+		form := url.Values{
+			"types":            {"public_channel,private_channel"},
+			"exclude_archived": {"true"},
+			"limit":            {"1000"},
+		}
+		if cursor != "" {
+			form.Set("cursor", cursor)
+		}
+		var page struct {
+			Channels []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"channels"`
+			ResponseMetadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}
+		if _, err := sc.callForm("conversations.list", form, &page); err != nil {
+			return "", fmt.Errorf("failed to look up channel #%s: %v", name, err)
+		}
+		for _, ch := range page.Channels {
+			if strings.EqualFold(ch.Name, name) {
+				return ch.ID, nil
+			}
+		}
+		if cursor = page.ResponseMetadata.NextCursor; cursor == "" {
+			return "", fmt.Errorf("channel #%s not found (or the bot cannot see it)", name)
+		}
+	}
+}
+
+// uploadFile shares a file in a channel with the external upload flow: get
+// an upload URL, send the bytes, then complete the upload.
+func (sc *slackClient) uploadFile(channelID, filename, title, comment, threadTs string, content []byte) ([]byte, error) {
+	var upload struct {
+		UploadURL string `json:"upload_url"`
+		FileID    string `json:"file_id"`
+	}
+	form := url.Values{"filename": {filename}, "length": {fmt.Sprint(len(content))}}
+	if _, err := sc.callForm("files.getUploadURLExternal", form, &upload); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", upload.UploadURL, bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create Slack upload request: %v", err)
+	}
+	req.Header.Set(ContentTypeHeader, "application/octet-stream")
+	resp, err := sc.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Slack file upload failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("Slack file upload returned HTTP %d", resp.StatusCode)
+	}
+
+	if title == "" {
+		title = filename
+	}
+	payload := map[string]interface{}{
+		"files":      []map[string]string{{"id": upload.FileID, "title": title}},
+		"channel_id": channelID,
+	}
+	if comment != "" {
+		payload["initial_comment"] = comment
+	}
+	if threadTs != "" {
+		payload["thread_ts"] = threadTs
+	}
+	return sc.call("files.completeUploadExternal", payload, nil)
+}
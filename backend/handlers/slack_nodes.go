@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// ==================== Slack Node Executors ====================
+
+// slackBlocksPlaceholder matches a blocks field that is a single {{path}}
+// placeholder, which takes the blocks from the run data as-is.
+var slackBlocksPlaceholder = regexp.MustCompile(`^{{\s*([A-Za-z0-9_\-.\[\]]+)\s*}}$`)
+
+// slackBlocks reads the node's Block Kit blocks: a JSON array of blocks, or
+// a Block Kit Builder payload ({"blocks": [...]}). String values in the JSON
+// are templated against the input. Returns nil when the field is empty.
+func slackBlocks(data, input map[string]interface{}) ([]interface{}, error) {
+	src, _ := data["blocks"].(string)
+	src = strings.TrimSpace(src)
+	if src == "" {
+		return nil, nil
+	}
+
+	var parsed interface{}
+	if m := slackBlocksPlaceholder.FindStringSubmatch(src); m != nil {
+		v, ok := lookupPath(input, m[1])
+		if !ok {
+			return nil, fmt.Errorf("blocks: %s is not in the input", m[1])
+		}
+		if s, ok := v.(string); ok {
+			if err := json.Unmarshal([]byte(s), &parsed); err != nil {
+				return nil, fmt.Errorf("blocks: %s is not Block Kit JSON", m[1])
+			}
+		} else {
+			parsed = v
+		}
+	} else {
+		if err := json.Unmarshal([]byte(src), &parsed); err != nil {
+			return nil, fmt.Errorf("blocks: invalid JSON: %v", err)
+		}
+		parsed = templateJSONValue(parsed, input)
+	}
+
+	if obj, ok := parsed.(map[string]interface{}); ok {
+		parsed = obj["blocks"]
+	}
+	blocks, ok := parsed.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf(`blocks must be a JSON array of blocks or {"blocks": [...]}`)
+	}
+	return blocks, nil
+}
+
+// slackErrorOutput keeps an error response as node output when it is JSON.
+func slackErrorOutput(body []byte) json.RawMessage {
+	if json.Valid(body) {
+		return json.RawMessage(body)
+	}
+	return nil
+}
+
+// executeSlackUploadFile shares a file built from run data in a channel.
+// The content is the templated content field, or the value at content_path
+// in the input (objects and arrays are written as indented JSON).
+func (h *Handler) executeSlackUploadFile(data map[string]interface{}, input json.RawMessage) (json.RawMessage, string) {
+	var inputMap map[string]interface{}
+	json.Unmarshal(input, &inputMap)
+
+	sc, err := h.slackClientForConnection(nodeConnectionID(data, inputMap))
+	if err != nil {
+		return nil, err.Error()
+	}
+	str := func(key string) string {
+		s, _ := data[key].(string)
+		return strings.TrimSpace(templateReplace(s, inputMap))
+	}
+
+	channel, filename := str("channel"), str("filename")
+	if channel == "" || filename == "" {
+		return nil, "Slack Upload File: channel and filename are required"
+	}
+
+	var content []byte
+	if path := str("content_path"); path != "" {
+		v, ok := lookupPath(inputMap, path)
+		if !ok {
+			return nil, fmt.Sprintf("Slack Upload File: %s is not in the input", path)
+		}
+		if s, ok := v.(string); ok {
+			content = []byte(s)
+		} else {
+			content, _ = json.MarshalIndent(v, "", "  ")
+		}
+	} else {
+		text, _ := data["content"].(string)
+		content = []byte(templateReplace(text, inputMap))
+	}
+	if data["base64"] == true || data["base64"] == "true" {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+		if err != nil {
+			return nil, fmt.Sprintf("Slack Upload File: content is not valid base64: %v", err)
+		}
+		content = decoded
+	}
+	if len(content) == 0 {
+		return nil, "Slack Upload File: the file is empty"
+	}
+
+	channelID, err := sc.resolveChannel(channel)
+	if err != nil {
+		return nil, "Slack Upload File: " + err.Error()
+	}
+	respBody, err := sc.uploadFile(channelID, filename, str("title"), str("initial_comment"), str("thread_ts"), content)
+	if err != nil {
+		return slackErrorOutput(respBody), err.Error()
+	}
+
+	log.Printf("📎 Slack file %s (%d bytes) shared in %s", filename, len(content), channelID)
+	return json.RawMessage(respBody), ""
+}
+
+// executeSlackLookupUser finds a Slack user by email, e.g. to DM them or
+// mention them (<@id>) in a later message.
+func (h *Handler) executeSlackLookupUser(data map[string]interface{}, input json.RawMessage) (json.RawMessage, string) {
+	var inputMap map[string]interface{}
+	json.Unmarshal(input, &inputMap)
+
+	sc, err := h.slackClientForConnection(nodeConnectionID(data, inputMap))
+	if err != nil {
+		return nil, err.Error()
+	}
+	email, _ := data["email"].(string)
+	email = strings.TrimSpace(templateReplace(email, inputMap))
+	if email == "" {
+		return nil, "Slack Lookup User: email is required"
+	}
+
+	user, err := sc.lookupUserByEmail(email)
+	if err != nil {
+		if apiErr, ok := err.(*slackAPIError); ok && apiErr.Code == "users_not_found" {
+			if data["fail_if_missing"] == true || data["fail_if_missing"] == "true" {
+				return nil, fmt.Sprintf("Slack Lookup User: no user with email %s", email)
+			}
+			out, _ := json.Marshal(map[string]interface{}{"found": false, "email": email})
+			return out, ""
+		}
+		return nil, err.Error()
+	}
+	out, _ := json.Marshal(map[string]interface{}{
+		"found":        true,
+		"id":           user.ID,
+		"mention":      "<@" + user.ID + ">",
+		"name":         user.Name,
+		"real_name":    user.RealName,
+		"display_name": user.Profile.DisplayName,
+		"email":        user.Profile.Email,
+		"tz":           user.TZ,
+		"is_bot":       user.IsBot,
+	})
+	return out, ""
+}
@@ -0,0 +1,369 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ==================== Workflow Triggers ====================
+
+const triggerColumns = "id, workflow_id, type, name, cron_schedule, node_id, input, enabled, paused, calendar_id, webhook_token, last_fired_at, created_at, updated_at"
+
+// Canonical trigger types. The start node and the legacy trigger endpoint
+// also accept "cron" and "trigger", which normaliseTriggerType maps here.
+const (
+	TriggerTypeManual   = "manual"
+	TriggerTypeSchedule = "schedule"
+	TriggerTypeWebhook  = "webhook"
+)
+
+func normaliseTriggerType(t string) string {
+	switch t {
+	case "cron":
+		return TriggerTypeSchedule
+	case "trigger":
+		return TriggerTypeWebhook
+	}
+	return t
+}
+
+func scanTrigger(scanner interface{ Scan(...interface{}) error }) (WorkflowTrigger, error) {
+	var t WorkflowTrigger
+	var input sql.NullString
+	err := scanner.Scan(&t.ID, &t.WorkflowID, &t.Type, &t.Name, &t.CronSchedule, &t.NodeID, &input, &t.Enabled, &t.Paused, &t.CalendarID, &t.WebhookToken, &t.LastFiredAt, &t.CreatedAt, &t.UpdatedAt)
+	if input.Valid && input.String != "" {
+		t.Input = json.RawMessage(input.String)
+	} else {
+		t.Input = json.RawMessage(`{}`)
+	}
+	if t.WebhookToken != nil {
+		t.WebhookPath = "/webhooks/w/" + *t.WebhookToken
+	}
+	return t, err
+}
+
+func (h *Handler) fetchTrigger(workflowID, triggerID string) (WorkflowTrigger, error) {
+	row := h.db.QueryRow("SELECT "+triggerColumns+" FROM workflow_triggers WHERE id = ? AND workflow_id = ?", triggerID, workflowID)
+	return scanTrigger(row)
+}
+
+func (h *Handler) getWorkflowTriggers(c *gin.Context) {
+	rows, err := h.db.Query("SELECT "+triggerColumns+" FROM workflow_triggers WHERE workflow_id = ? ORDER BY created_at", c.Param("id"))
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	triggers := []WorkflowTrigger{}
+	for rows.Next() {
+		t, err := scanTrigger(rows)
+		if err != nil {
+			log.Printf("Failed to scan trigger row: %v", err)
+			continue
+		}
+		triggers = append(triggers, t)
+	}
+	c.JSON(200, triggers)
+}
+
+type triggerRequest struct {
+	Type         string          `json:"type"`
+	Name         string          `json:"name"`
+	CronSchedule *string         `json:"cron_schedule"`
+	Input        json.RawMessage `json:"input"`
+	Enabled      *bool           `json:"enabled"`
+	CalendarID   *string         `json:"calendar_id"`
+}
+
+// validate checks the request for a trigger of the given type and returns a
+// user-facing error message, or "" if it is valid.
+func (req *triggerRequest) validate(triggerType string) string {
+	if triggerType == TriggerTypeSchedule {
+		if req.CronSchedule == nil || *req.CronSchedule == "" {
+			return "cron_schedule is required for schedule triggers"
+		}
+		if _, err := parseCronSchedule(*req.CronSchedule); err != nil {
+			return "Invalid cron schedule: " + err.Error()
+		}
+	}
+	if req.CalendarID != nil && *req.CalendarID != "" && triggerType != TriggerTypeSchedule {
+		return "calendar_id only applies to schedule triggers"
+	}
+	if len(req.Input) > 0 && string(req.Input) != "null" {
+		var obj map[string]interface{}
+		if err := json.Unmarshal(req.Input, &obj); err != nil {
+			return "input must be a JSON object"
+		}
+	}
+	return ""
+}
+
+func (h *Handler) createWorkflowTrigger(c *gin.Context) {
+	workflowID := c.Param("id")
+	var req triggerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.Type = normaliseTriggerType(req.Type)
+	if req.Type != TriggerTypeManual && req.Type != TriggerTypeSchedule {
+		c.JSON(400, gin.H{"error": "type must be 'manual' or 'schedule'; webhook triggers are created by adding a trigger node to the workflow"})
+		return
+	}
+	if msg := req.validate(req.Type); msg != "" {
+		c.JSON(400, gin.H{"error": msg})
+		return
+	}
+	if req.Type != TriggerTypeSchedule {
+		req.CronSchedule = nil
+	}
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	if len(req.Input) == 0 {
+		req.Input = json.RawMessage(`{}`)
+	}
+	calendarID, msg := h.resolveCalendarRef(req.CalendarID)
+	if msg != "" {
+		c.JSON(400, gin.H{"error": msg})
+		return
+	}
+
+	id := uuid.New().String()
+	_, err := h.db.Exec(
+		"INSERT INTO workflow_triggers (id, workflow_id, type, name, cron_schedule, input, enabled, calendar_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		id, workflowID, req.Type, req.Name, req.CronSchedule, req.Input, enabled, calendarID,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.syncWorkflowTriggerSummary(workflowID)
+	h.refreshWorkflowSchedule(workflowID)
+	c.JSON(201, gin.H{"id": id, "message": "Trigger created"})
+}
+
+// updateWorkflowTriggerByID updates name, schedule, static input, blackout
+// calendar and the enabled flag. Node-derived triggers keep the schedule from their node.
+func (h *Handler) updateWorkflowTriggerByID(c *gin.Context) {
+	workflowID := c.Param("id")
+	existing, err := h.fetchTrigger(workflowID, c.Param("triggerId"))
+	if err == sql.ErrNoRows {
+		c.JSON(404, gin.H{"error": "Trigger not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req triggerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if existing.NodeID != nil || req.CronSchedule == nil {
+		req.CronSchedule = existing.CronSchedule
+	}
+	if msg := req.validate(existing.Type); msg != "" {
+		c.JSON(400, gin.H{"error": msg})
+		return
+	}
+	if req.Name == "" {
+		req.Name = existing.Name
+	}
+	if len(req.Input) == 0 {
+		req.Input = existing.Input
+	}
+	enabled := existing.Enabled
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	// calendar_id: omitted keeps the current calendar, "" detaches it.
+	if req.CalendarID == nil {
+		req.CalendarID = existing.CalendarID
+	}
+	calendarID, msg := h.resolveCalendarRef(req.CalendarID)
+	if msg != "" {
+		c.JSON(400, gin.H{"error": msg})
+		return
+	}
+
+	_, err = h.db.Exec(
+		"UPDATE workflow_triggers SET name = ?, cron_schedule = ?, input = ?, enabled = ?, calendar_id = ? WHERE id = ?",
+		req.Name, req.CronSchedule, req.Input, enabled, calendarID, existing.ID,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.syncWorkflowTriggerSummary(workflowID)
+	h.refreshWorkflowSchedule(workflowID)
+	c.JSON(200, gin.H{"message": "Trigger updated"})
+}
+
+func (h *Handler) deleteWorkflowTrigger(c *gin.Context) {
+	workflowID := c.Param("id")
+	existing, err := h.fetchTrigger(workflowID, c.Param("triggerId"))
+	if err == sql.ErrNoRows {
+		c.JSON(404, gin.H{"error": "Trigger not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if existing.NodeID != nil {
+		c.JSON(400, gin.H{"error": "This trigger comes from a node in the workflow; remove the node or disable the trigger instead"})
+		return
+	}
+
+	if _, err := h.db.Exec("DELETE FROM workflow_triggers WHERE id = ?", existing.ID); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.syncWorkflowTriggerSummary(workflowID)
+	h.refreshWorkflowSchedule(workflowID)
+	c.JSON(200, gin.H{"message": "Trigger deleted"})
+}
+
+// runWorkflowTrigger fires a trigger by hand. The request input is merged
+// over the trigger's static input.
+func (h *Handler) runWorkflowTrigger(c *gin.Context) {
+	workflowID := c.Param("id")
+	t, err := h.fetchTrigger(workflowID, c.Param("triggerId"))
+	if err == sql.ErrNoRows {
+		c.JSON(404, gin.H{"error": "Trigger not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if !t.Enabled {
+		c.JSON(409, gin.H{"error": "Trigger is disabled"})
+		return
+	}
+
+	var req struct {
+		Input json.RawMessage `json:"input"`
+	}
+	c.ShouldBindJSON(&req)
+
+	var w Workflow
+	err = h.db.QueryRow("SELECT id, name, nodes, edges FROM workflows WHERE id = ?", workflowID).
+		Scan(&w.ID, &w.Name, &w.Nodes, &w.Edges)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "Workflow not found"})
+		return
+	}
+
+	runID, err := h.startRun(w, mergeJSONObjects(t.Input, req.Input), runOptions{TriggerID: &t.ID, StartNodeID: triggerNodeID(t)})
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	h.markTriggerFired(t.ID)
+
+	c.JSON(200, gin.H{
+		"run_id":     runID,
+		"trigger_id": t.ID,
+		"status":     "running",
+		"message":    "Workflow started",
+	})
+}
+
+// resolveCalendarRef validates a calendar reference, turning "" into nil.
+// It returns a user-facing error message, or "" if the reference is valid.
+func (h *Handler) resolveCalendarRef(calendarID *string) (*string, string) {
+	if calendarID == nil || *calendarID == "" {
+		return nil, ""
+	}
+	if _, err := h.fetchCalendar(*calendarID); err != nil {
+		return nil, "Calendar not found"
+	}
+	return calendarID, ""
+}
+
+// markTriggerFired stamps last_fired_at on a trigger.
+func (h *Handler) markTriggerFired(triggerID string) {
+	h.db.Exec("UPDATE workflow_triggers SET last_fired_at = NOW() WHERE id = ?", triggerID)
+}
+
+// findNodeTrigger returns the trigger derived from a workflow node, if any.
+func (h *Handler) findNodeTrigger(workflowID, nodeID string) (WorkflowTrigger, bool) {
+	row := h.db.QueryRow("SELECT "+triggerColumns+" FROM workflow_triggers WHERE workflow_id = ? AND node_id = ?", workflowID, nodeID)
+	t, err := scanTrigger(row)
+	return t, err == nil
+}
+
+// upsertNodeTrigger creates or updates the trigger derived from a node,
+// leaving its enabled flag alone. With replaceInput the node owns the static
+// input and it is set to input, NULL when nil; otherwise a nil input keeps
+// the current one.
+func (h *Handler) upsertNodeTrigger(workflowID, nodeID, triggerType, name string, cronSchedule *string, input json.RawMessage, replaceInput bool) {
+	_, err := h.db.Exec(
+		`INSERT INTO workflow_triggers (id, workflow_id, type, name, cron_schedule, node_id, input)
+		 VALUES (?, ?, ?, ?, ?, ?, COALESCE(?, '{}'))
+		 ON DUPLICATE KEY UPDATE type = VALUES(type), cron_schedule = VALUES(cron_schedule),
+		   input = IF(?, ?, COALESCE(?, input))`,
+		uuid.New().String(), workflowID, triggerType, name, cronSchedule, nodeID, input, replaceInput, input, input,
+	)
+	if err != nil {
+		log.Printf("Failed to sync trigger for node %s on workflow %s: %v", nodeID, workflowID, err)
+	}
+}
+
+// ensureWebhookToken gives a node-derived trigger a random URL token the
+// first time it is synced; later saves keep the same URL.
+func (h *Handler) ensureWebhookToken(workflowID, nodeID string) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		log.Printf("Failed to generate webhook token: %v", err)
+		return
+	}
+	h.db.Exec(
+		"UPDATE workflow_triggers SET webhook_token = ? WHERE workflow_id = ? AND node_id = ? AND webhook_token IS NULL",
+		hex.EncodeToString(buf), workflowID, nodeID,
+	)
+}
+
+// syncWorkflowTriggerSummary keeps workflows.trigger_type/cron_schedule as a
+// summary of the enabled triggers, for list views and older clients.
+func (h *Handler) syncWorkflowTriggerSummary(workflowID string) {
+	triggerType := TriggerTypeManual
+	var cronSchedule *string
+
+	rows, err := h.db.Query("SELECT type, cron_schedule FROM workflow_triggers WHERE workflow_id = ? AND enabled = TRUE ORDER BY created_at", workflowID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t string
+		var cron *string
+		if err := rows.Scan(&t, &cron); err != nil {
+			continue
+		}
+		switch {
+		case t == TriggerTypeSchedule && cronSchedule == nil:
+			triggerType, cronSchedule = TriggerTypeSchedule, cron
+		case t == TriggerTypeWebhook && triggerType == TriggerTypeManual:
+			triggerType = TriggerTypeWebhook
+		}
+	}
+
+	h.db.Exec("UPDATE workflows SET trigger_type = ?, cron_schedule = ? WHERE id = ?", triggerType, cronSchedule, workflowID)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ==================== Webhook Deduplication ====================
+
+const defaultWebhookDedupWindow = 24 * time.Hour
+
+// deliveryIDHeaders are checked, in order, for a sender-assigned delivery ID
+// that stays the same across retries.
+var deliveryIDHeaders = []string{"Idempotency-Key", "X-Delivery-Id", "X-Webhook-Id"}
+
+// webhookDedupWindow is how long a dedup key blocks redeliveries, from
+// WEBHOOK_DEDUP_WINDOW (a Go duration such as "24h"; "0" disables dedup).
+func webhookDedupWindow() time.Duration {
+	if v := os.Getenv("WEBHOOK_DEDUP_WINDOW"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err == nil && d >= 0 {
+			return d
+		}
+		log.Printf("Invalid WEBHOOK_DEDUP_WINDOW %q, using %s", v, defaultWebhookDedupWindow)
+	}
+	return defaultWebhookDedupWindow
+}
+
+// dedupKey hashes the parts identifying a delivery into a fixed-size key.
+// It returns "" when any part is missing, which disables dedup for the event.
+func dedupKey(parts ...string) string {
+	for _, p := range parts {
+		if p == "" {
+			return ""
+		}
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// jiraDedupKey prefers Jira's per-delivery identifier header and otherwise
+// combines the payload timestamp, issue id and webhookEvent, all of which
+// Jira keeps identical when it retries.
+func jiraDedupKey(header http.Header, payload map[string]interface{}) string {
+	if id := header.Get("X-Atlassian-Webhook-Identifier"); id != "" {
+		return dedupKey("delivery", id)
+	}
+	var timestamp, issueID string
+	if ts, ok := payload["timestamp"].(float64); ok {
+		timestamp = fmt.Sprintf("%.0f", ts)
+	}
+	if issue, ok := payload["issue"].(map[string]interface{}); ok {
+		issueID, _ = issue["id"].(string)
+	}
+	eventType, _ := payload["webhookEvent"].(string)
+	return dedupKey(timestamp, issueID, eventType)
+}
+
+// deliveryDedupKey derives a key from a delivery ID header, scoped to the
+// receiving trigger so two workflows never share a key.
+func deliveryDedupKey(scope string, header http.Header) string {
+	for _, name := range deliveryIDHeaders {
+		if id := header.Get(name); id != "" {
+			return dedupKey(scope, id)
+		}
+	}
+	return ""
+}
+
+// inboundEvent is a webhook delivery about to be stored.
+type inboundEvent struct {
+	ID           string
+	Source       string
+	EventType    string
+	Payload      []byte
+	Verification string
+	Quarantined  bool
+	DedupKey     string
+}
+
+// storeWebhookEvent inserts the event under its dedup key. If an event with
+// the same key was stored within the dedup window it stores nothing and
+// returns that event's ID. A key older than the window is released first.
+func (h *Handler) storeWebhookEvent(e inboundEvent) (string, error) {
+	if e.Verification == "" {
+		e.Verification = VerificationNotConfigured
+	}
+	window := webhookDedupWindow()
+	var key *string
+	if e.DedupKey != "" && window > 0 {
+		key = &e.DedupKey
+		if dup := h.findDuplicateEvent(e.Source, e.DedupKey, window); dup != "" {
+			return dup, nil
+		}
+	}
+
+	_, err := h.db.Exec(
+		"INSERT INTO webhook_events (id, source, event_type, payload, verification, quarantined, dedup_key) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		e.ID, e.Source, e.EventType, e.Payload, e.Verification, e.Quarantined, key,
+	)
+	var mysqlErr *mysql.MySQLError
+	if key != nil && errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		// A concurrent retry won the race.
+		if dup := h.findDuplicateEvent(e.Source, e.DedupKey, window); dup != "" {
+			return dup, nil
+		}
+	}
+	return "", err
+}
+
+// findDuplicateEvent returns the event holding the key if it is within the
+// window, releasing the key if it has expired.
+func (h *Handler) findDuplicateEvent(source, key string, window time.Duration) string {
+	var id string
+	var createdAt time.Time
+	err := h.db.QueryRow("SELECT id, created_at FROM webhook_events WHERE source = ? AND dedup_key = ?", source, key).
+		Scan(&id, &createdAt)
+	if err != nil {
+		return ""
+	}
+	if time.Since(createdAt) < window {
+		return id
+	}
+	h.db.Exec("UPDATE webhook_events SET dedup_key = NULL WHERE id = ?", id)
+	return ""
+}
@@ -0,0 +1,399 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== Webhook Event Log ====================
+
+const (
+	defaultWebhookEventPage       = 50
+	maxWebhookEventPage           = 200
+	webhookRetentionBatchSize     = 1000
+	webhookRetentionSweepInterval = time.Hour
+)
+
+const webhookEventColumns = "id, source, event_type, payload, processed, workflow_run_id, verification, quarantined, match_results, skip_reason, created_at"
+
+func scanWebhookEvent(scanner interface{ Scan(...interface{}) error }) (WebhookEvent, error) {
+	var e WebhookEvent
+	err := scanner.Scan(&e.ID, &e.Source, &e.EventType, &e.Payload, &e.Processed, &e.WorkflowRunID, &e.Verification, &e.Quarantined, &e.MatchResults, &e.SkipReason, &e.CreatedAt)
+	return e, err
+}
+
+// encodeEventCursor and decodeEventCursor turn the (created_at, id) of the
+// last event on a page into an opaque cursor for the next one.
+func encodeEventCursor(e WebhookEvent) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(e.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + e.ID))
+}
+
+func decodeEventCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, "", fmt.Errorf("malformed cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	return t, id, err
+}
+
+// getWebhookEvents lists events newest first. Filters: source, event_type,
+// processed, quarantined, from/to (RFC3339) and json_path (e.g.
+// $.issue.key) with an optional json_value to compare it against. Pages are
+// chained with the returned next_cursor.
+func (h *Handler) getWebhookEvents(c *gin.Context) {
+	var where []string
+	var args []interface{}
+
+	for _, col := range []string{"source", "event_type"} {
+		if v := c.Query(col); v != "" {
+			where = append(where, col+" = ?")
+			args = append(args, v)
+		}
+	}
+	for _, col := range []string{"processed", "quarantined"} {
+		if v := c.Query(col); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				c.JSON(400, gin.H{"error": col + " must be true or false"})
+				return
+			}
+			where = append(where, col+" = ?")
+			args = append(args, b)
+		}
+	}
+	for param, op := range map[string]string{"from": ">=", "to": "<"} {
+		if v := c.Query(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				c.JSON(400, gin.H{"error": param + " must be an RFC3339 timestamp"})
+				return
+			}
+			where = append(where, "created_at "+op+" ?")
+			args = append(args, t)
+		}
+	}
+	if path := c.Query("json_path"); path != "" {
+		if !strings.HasPrefix(path, "$") {
+			c.JSON(400, gin.H{"error": "json_path must start with $, e.g. $.issue.key"})
+			return
+		}
+		if value, ok := c.GetQuery("json_value"); ok {
+			where = append(where, "JSON_UNQUOTE(JSON_EXTRACT(payload, ?)) = ?")
+			args = append(args, path, value)
+		} else {
+			where = append(where, "JSON_CONTAINS_PATH(payload, 'one', ?)")
+			args = append(args, path)
+		}
+	}
+	if cursor := c.Query("cursor"); cursor != "" {
+		t, id, err := decodeEventCursor(cursor)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		where = append(where, "(created_at < ? OR (created_at = ? AND id < ?))")
+		args = append(args, t, t, id)
+	}
+
+	limit := defaultWebhookEventPage
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			c.JSON(400, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = n
+	}
+	if limit > maxWebhookEventPage {
+		limit = maxWebhookEventPage
+	}
+
+	query := "SELECT " + webhookEventColumns + " FROM webhook_events"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT ?"
+	args = append(args, limit+1)
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	events := []WebhookEvent{}
+	for rows.Next() {
+		e, err := scanWebhookEvent(rows)
+		if err != nil {
+			continue
+		}
+		events = append(events, e)
+	}
+	rows.Close()
+
+	var nextCursor *string
+	if len(events) > limit {
+		events = events[:limit]
+		cursor := encodeEventCursor(events[limit-1])
+		nextCursor = &cursor
+	}
+	h.attachEventRuns(events)
+	c.JSON(200, gin.H{"events": events, "next_cursor": nextCursor})
+}
+
+// getWebhookEvent returns one event with the runs it started.
+func (h *Handler) getWebhookEvent(c *gin.Context) {
+	e, err := scanWebhookEvent(h.db.QueryRow("SELECT "+webhookEventColumns+" FROM webhook_events WHERE id = ?", c.Param("id")))
+	if err == sql.ErrNoRows {
+		c.JSON(404, gin.H{"error": "Webhook event not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	events := []WebhookEvent{e}
+	h.attachEventRuns(events)
+	c.JSON(200, events[0])
+}
+
+// attachEventRuns fills in the runs started by each event.
+func (h *Handler) attachEventRuns(events []WebhookEvent) {
+	if len(events) == 0 {
+		return
+	}
+	index := make(map[string]int, len(events))
+	args := make([]interface{}, len(events))
+	for i := range events {
+		events[i].Runs = []EventRun{}
+		index[events[i].ID] = i
+		args[i] = events[i].ID
+	}
+	rows, err := h.db.Query(
+		"SELECT er.event_id, er.workflow_run_id, er.workflow_id, er.trigger_id, COALESCE(r.status, ''), er.replay, er.created_at "+
+			"FROM webhook_event_runs er LEFT JOIN workflow_runs r ON r.id = er.workflow_run_id "+
+			"WHERE er.event_id IN (?"+strings.Repeat(", ?", len(events)-1)+") ORDER BY er.created_at",
+		args...,
+	)
+	if err != nil {
+		log.Printf("Failed to load webhook event runs: %v", err)
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var eventID string
+		var r EventRun
+		if err := rows.Scan(&eventID, &r.RunID, &r.WorkflowID, &r.TriggerID, &r.Status, &r.Replay, &r.CreatedAt); err != nil {
+			continue
+		}
+		if i, ok := index[eventID]; ok {
+			events[i].Runs = append(events[i].Runs, r)
+		}
+	}
+}
+
+// replayWebhookEvent re-delivers a stored event: trigger matching runs again
+// with the stored payload, optionally against a single workflow, and every run
+// it starts is marked as a replay of the event. Quarantined events can be
+// replayed too, which is how an operator releases them; releasing clears the
+// quarantine. Events that failed verification and were rejected outright
+// cannot be replayed.
+func (h *Handler) replayWebhookEvent(c *gin.Context) {
+	eventID := c.Param("id")
+	var req struct {
+		WorkflowID string `json:"workflow_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var source, eventType, verification string
+	var payload []byte
+	var quarantined bool
+	err := h.db.QueryRow("SELECT source, event_type, payload, verification, quarantined FROM webhook_events WHERE id = ?", eventID).
+		Scan(&source, &eventType, &payload, &verification, &quarantined)
+	if err == sql.ErrNoRows {
+		c.JSON(404, gin.H{"error": "Webhook event not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if !quarantined && (verification == VerificationInvalid || verification == VerificationUnsigned) {
+		c.JSON(409, gin.H{"error": "Event was rejected because it failed signature verification (" + verification + ") and cannot be replayed"})
+		return
+	}
+	if req.WorkflowID != "" {
+		var exists int
+		if h.db.QueryRow("SELECT 1 FROM workflows WHERE id = ?", req.WorkflowID).Scan(&exists) != nil {
+			c.JSON(404, gin.H{"error": "Workflow not found"})
+			return
+		}
+	}
+
+	opts := runOptions{ReplayOf: &eventID}
+	var results []TriggerMatch
+	switch source {
+	case "jira":
+		results = h.matchJiraEvent(eventID, eventType, payload, req.WorkflowID, opts)
+	case "generic":
+		results = h.replayGenericEvent(eventID, payload, req.WorkflowID, opts)
+	case "github":
+		results = h.matchGitHubEvent(eventID, eventType, payload, req.WorkflowID, opts)
+	case "slack":
+		results = h.matchSlackEvent(eventID, payload, req.WorkflowID, opts)
+	default:
+		c.JSON(400, gin.H{"error": "Replay is not supported for " + source + " events"})
+		return
+	}
+
+	if quarantined {
+		if _, err := h.db.Exec("UPDATE webhook_events SET quarantined = FALSE WHERE id = ?", eventID); err != nil {
+			log.Printf("Failed to release quarantined webhook event %s: %v", eventID, err)
+		} else {
+			log.Printf("🔓 Released quarantined webhook event %s (%s)", eventID, verification)
+		}
+	}
+
+	runs := []string{}
+	for _, m := range results {
+		if m.RunID != "" {
+			runs = append(runs, m.RunID)
+		}
+	}
+	log.Printf("🔁 Replayed webhook event %s (%s): %d run(s) started", eventID, eventType, len(runs))
+	c.JSON(200, gin.H{"event_id": eventID, "runs": runs, "match_results": results})
+}
+
+// replayGenericEvent restarts the workflow whose webhook URL received the
+// event. A generic event always belongs to exactly one trigger.
+func (h *Handler) replayGenericEvent(eventID string, payload []byte, workflowID string, opts runOptions) []TriggerMatch {
+	var stored struct {
+		Webhook struct {
+			TriggerID string `json:"trigger_id"`
+		} `json:"webhook"`
+	}
+	json.Unmarshal(payload, &stored)
+
+	row := h.db.QueryRow("SELECT "+triggerColumns+" FROM workflow_triggers WHERE id = ?", stored.Webhook.TriggerID)
+	t, err := scanTrigger(row)
+	if err != nil {
+		return []TriggerMatch{{WorkflowID: workflowID, Reason: "the webhook trigger that received this event no longer exists"}}
+	}
+	match := TriggerMatch{WorkflowID: t.WorkflowID}
+	if t.NodeID != nil {
+		match.NodeID = *t.NodeID
+	}
+	if workflowID != "" && workflowID != t.WorkflowID {
+		match.WorkflowID = workflowID
+		match.Reason = "event was received by another workflow's webhook"
+		return []TriggerMatch{match}
+	}
+
+	var w Workflow
+	err = h.db.QueryRow("SELECT id, name, nodes, edges, status FROM workflows WHERE id = ?", t.WorkflowID).
+		Scan(&w.ID, &w.Name, &w.Nodes, &w.Edges, &w.Status)
+	if err != nil {
+		match.Reason = "workflow not found"
+		return []TriggerMatch{match}
+	}
+	if (workflowID == "" && w.Status != "active") || !t.Enabled {
+		match.Reason = "workflow or trigger is not active"
+		return []TriggerMatch{match}
+	}
+	input := json.RawMessage(payload)
+	if t.NodeID != nil {
+		nodeData, _ := findNodeData(w.Nodes, *t.NodeID)
+		var parsed map[string]interface{}
+		json.Unmarshal(payload, &parsed)
+		if !applyFilterExpression(nodeData, parsed, &match) {
+			return []TriggerMatch{match}
+		}
+		mapped, err := applyInputMapping(nodeData, parsed)
+		if err != nil {
+			match.Reason = "invalid input mapping: " + err.Error()
+			return []TriggerMatch{match}
+		}
+		if mapped != nil {
+			input = mapped
+			opts.TriggerPayload = payload
+		}
+	}
+
+	opts.TriggerID = &t.ID
+	opts.StartNodeID = triggerNodeID(t)
+	runID, err := h.startRun(w, mergeJSONObjects(t.Input, input), opts)
+	if err != nil {
+		match.Reason = "failed to start run: " + err.Error()
+		return []TriggerMatch{match}
+	}
+	h.markTriggerFired(t.ID)
+	h.linkEventRun(eventID, runID, w.ID, opts)
+	match.Matched = true
+	match.RunID = runID
+	return []TriggerMatch{match}
+}
+
+// StartWebhookEventRetention prunes webhook events, payloads included, older
+// than WEBHOOK_EVENT_RETENTION_DAYS. Unset or 0 keeps events forever, so
+// pruning is opt-in. Deletes run in small batches so the table is never
+// locked for long.
+func (h *Handler) StartWebhookEventRetention() {
+	days := 0
+	if v := os.Getenv("WEBHOOK_EVENT_RETENTION_DAYS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Printf("Invalid WEBHOOK_EVENT_RETENTION_DAYS %q, keeping events forever", v)
+		} else {
+			days = n
+		}
+	}
+	if days == 0 {
+		log.Println("🧹 Webhook event retention disabled")
+		return
+	}
+	log.Printf("🧹 Webhook event retention: %d days", days)
+
+	for {
+		h.pruneWebhookEvents(time.Now().AddDate(0, 0, -days))
+		time.Sleep(webhookRetentionSweepInterval)
+	}
+}
+
+func (h *Handler) pruneWebhookEvents(cutoff time.Time) {
+	var total int64
+	for {
+		res, err := h.db.Exec("DELETE FROM webhook_events WHERE created_at < ? ORDER BY created_at LIMIT ?", cutoff, webhookRetentionBatchSize)
+		if err != nil {
+			log.Printf("Failed to prune webhook events: %v", err)
+			return
+		}
+		n, _ := res.RowsAffected()
+		total += n
+		if n < webhookRetentionBatchSize {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if total > 0 {
+		log.Printf("🧹 Pruned %d webhook events older than %s", total, cutoff.Format(time.RFC3339))
+	}
+}
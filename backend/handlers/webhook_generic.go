@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ==================== Generic Webhook Receiver ====================
+
+// maxWebhookBodyBytes caps inbound webhook bodies.
+const maxWebhookBodyBytes = 5 << 20
+
+// findNodeData returns the data map of the node with the given id.
+func findNodeData(nodesJSON json.RawMessage, nodeID string) (map[string]interface{}, bool) {
+	var nodes []map[string]interface{}
+	if err := json.Unmarshal(nodesJSON, &nodes); err != nil {
+		return nil, false
+	}
+	for _, n := range nodes {
+		if id, _ := n["id"].(string); id == nodeID {
+			data, _ := n["data"].(map[string]interface{})
+			if data == nil {
+				data = map[string]interface{}{}
+			}
+			return data, true
+		}
+	}
+	return nil, false
+}
+
+// allowedWebhookMethods parses the node's comma-separated allowed_methods,
+// defaulting to POST.
+func allowedWebhookMethods(data map[string]interface{}) map[string]bool {
+	raw, _ := data["allowed_methods"].(string)
+	methods := map[string]bool{}
+	for _, m := range strings.Split(raw, ",") {
+		if m = strings.ToUpper(strings.TrimSpace(m)); m != "" {
+			methods[m] = true
+		}
+	}
+	if len(methods) == 0 {
+		methods[http.MethodPost] = true
+	}
+	return methods
+}
+
+// parseWebhookBody decodes a request body by content type: JSON into its
+// value, form posts into a field map and anything else as plain text.
+func parseWebhookBody(contentType string, body []byte) interface{} {
+	if len(body) == 0 {
+		return nil
+	}
+	ct := strings.ToLower(contentType)
+	switch {
+	case strings.Contains(ct, "json"):
+		var v interface{}
+		if json.Unmarshal(body, &v) == nil {
+			return v
+		}
+	case strings.HasPrefix(ct, "application/x-www-form-urlencoded"):
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			return flattenValues(values)
+		}
+	}
+	return string(body)
+}
+
+// flattenValues turns url.Values into a JSON-friendly map, keeping a list
+// only for keys that repeat.
+func flattenValues(values map[string][]string) map[string]interface{} {
+	out := make(map[string]interface{}, len(values))
+	for k, vs := range values {
+		if len(vs) == 1 {
+			out[k] = vs[0]
+		} else {
+			out[k] = vs
+		}
+	}
+	return out
+}
+
+// handleGenericWebhook receives calls to a workflow's webhook URL and starts
+// the workflow with the method, headers, query and body as its input.
+func (h *Handler) handleGenericWebhook(c *gin.Context) {
+	row := h.db.QueryRow("SELECT "+triggerColumns+" FROM workflow_triggers WHERE webhook_token = ?", c.Param("token"))
+	t, err := scanTrigger(row)
+	if err != nil || t.NodeID == nil {
+		c.JSON(404, gin.H{"error": "Unknown webhook"})
+		return
+	}
+	var w Workflow
+	err = h.db.QueryRow("SELECT id, name, nodes, edges, status FROM workflows WHERE id = ?", t.WorkflowID).
+		Scan(&w.ID, &w.Name, &w.Nodes, &w.Edges, &w.Status)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "Unknown webhook"})
+		return
+	}
+	nodeData, ok := findNodeData(w.Nodes, *t.NodeID)
+	if !ok {
+		c.JSON(404, gin.H{"error": "Unknown webhook"})
+		return
+	}
+
+	method := c.Request.Method
+	if !allowedWebhookMethods(nodeData)[method] {
+		c.JSON(405, gin.H{"error": "Method not allowed: " + method})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodyBytes))
+	if err != nil {
+		c.JSON(413, gin.H{"error": "Failed to read body"})
+		return
+	}
+	var parsedBody interface{}
+	if strings.HasPrefix(strings.ToLower(c.ContentType()), "multipart/form-data") {
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if form, err := c.MultipartForm(); err == nil {
+			parsedBody = flattenValues(form.Value)
+		}
+	} else {
+		parsedBody = parseWebhookBody(c.ContentType(), body)
+	}
+
+	headers := map[string]string{}
+	for k, vs := range c.Request.Header {
+		headers[strings.ToLower(k)] = strings.Join(vs, ", ")
+	}
+
+	eventID := uuid.New().String()
+	eventType := "webhook." + strings.ToLower(method)
+	payload, _ := json.Marshal(map[string]interface{}{
+		"method":  method,
+		"path":    c.Request.URL.Path,
+		"headers": headers,
+		"query":   flattenValues(c.Request.URL.Query()),
+		"body":    parsedBody,
+		"webhook": map[string]interface{}{
+			"trigger_id":  t.ID,
+			"event_id":    eventID,
+			"received_at": time.Now().Format(time.RFC3339),
+		},
+	})
+
+	duplicateOf, err := h.storeWebhookEvent(inboundEvent{
+		ID: eventID, Source: "generic", EventType: eventType, Payload: payload,
+		DedupKey: deliveryDedupKey(t.ID, c.Request.Header),
+	})
+	if err != nil {
+		log.Printf("Failed to store webhook event: %v", err)
+		c.JSON(500, gin.H{"error": "Failed to store webhook event"})
+		return
+	}
+	if duplicateOf != "" {
+		log.Printf("♻️ Duplicate webhook delivery for workflow '%s' ignored (event_id=%s)", w.Name, duplicateOf)
+		c.JSON(200, gin.H{"status": "duplicate", "event_id": duplicateOf})
+		return
+	}
+	log.Printf("📩 Generic webhook received for workflow '%s': %s (event_id=%s)", w.Name, eventType, eventID)
+
+	if w.Status != "active" || !t.Enabled {
+		c.JSON(200, gin.H{"status": "ignored", "event_id": eventID, "reason": "workflow or trigger is not active"})
+		return
+	}
+
+	match := TriggerMatch{WorkflowID: w.ID, NodeID: *t.NodeID}
+	var parsed map[string]interface{}
+	json.Unmarshal(payload, &parsed)
+	if !applyFilterExpression(nodeData, parsed, &match) {
+		h.recordMatchResults(eventID, []TriggerMatch{match})
+		c.JSON(200, gin.H{"status": "ignored", "event_id": eventID, "reason": match.Reason})
+		return
+	}
+
+	// A workflow with a webhook_response node answers the caller itself, so
+	// hold the request until it does, the run ends or the timeout passes.
+	opts := runOptions{TriggerID: &t.ID, StartNodeID: *t.NodeID}
+	if workflowHasNode(w.Nodes, "webhook_response") {
+		opts.Response = make(chan webhookResponse, 1)
+	}
+	input := json.RawMessage(payload)
+	mapped, err := applyInputMapping(nodeData, parsed)
+	if err != nil {
+		match.Reason = "invalid input mapping: " + err.Error()
+		h.recordMatchResults(eventID, []TriggerMatch{match})
+		c.JSON(500, gin.H{"error": "Webhook node has an " + match.Reason, "event_id": eventID})
+		return
+	}
+	if mapped != nil {
+		input = mapped
+		opts.TriggerPayload = payload
+	}
+	runID, err := h.startRun(w, mergeJSONObjects(t.Input, input), opts)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	h.markTriggerFired(t.ID)
+	h.linkEventRun(eventID, runID, w.ID, opts)
+	match.Matched, match.RunID = true, runID
+	h.recordMatchResults(eventID, []TriggerMatch{match})
+
+	if opts.Response == nil {
+		c.JSON(200, gin.H{"status": "received", "event_id": eventID, "run_id": runID})
+		return
+	}
+	timer := time.NewTimer(webhookResponseTimeout(nodeData))
+	defer timer.Stop()
+	select {
+	case resp := <-opts.Response:
+		writeWebhookResponse(c, resp)
+	case <-timer.C:
+		h.responses.forget(runID)
+		c.JSON(504, gin.H{"error": "Workflow did not respond in time", "event_id": eventID, "run_id": runID})
+	case <-c.Request.Context().Done():
+		h.responses.forget(runID)
+	}
+}
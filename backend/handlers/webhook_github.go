@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ==================== GitHub Webhook Receiver ====================
+
+// verifyGitHubWebhook checks X-Hub-Signature-256 against the webhook_secret
+// of its github connection. Deliveries are rejected until a secret is set.
+func verifyGitHubWebhook(c *gin.Context, config map[string]interface{}, body []byte) string {
+	secret, _ := config["webhook_secret"].(string)
+	if secret == "" {
+		return VerificationNotConfigured
+	}
+	sig := c.GetHeader("X-Hub-Signature-256")
+	if sig == "" {
+		return VerificationUnsigned
+	}
+	if verifyPrefixedHMAC(secret, body, sig) {
+		return VerificationVerified
+	}
+	return VerificationInvalid
+}
+
+// githubEventType combines the X-GitHub-Event header with the payload action,
+// e.g. "pull_request.opened", or just "push" for events without one.
+func githubEventType(event, action string) string {
+	if action == "" {
+		return event
+	}
+	return event + "." + action
+}
+
+// githubBranch returns the branch an event concerns, if any: the pushed
+// ref, the base branch of a pull request or the head branch of a check.
+func githubBranch(event string, payload map[string]interface{}) string {
+	str := func(m map[string]interface{}, key string) string {
+		s, _ := m[key].(string)
+		return s
+	}
+	obj := func(key string) map[string]interface{} {
+		m, _ := payload[key].(map[string]interface{})
+		return m
+	}
+
+	switch event {
+	case "push":
+		branch, _ := strings.CutPrefix(str(payload, "ref"), "refs/heads/")
+		if strings.HasPrefix(branch, "refs/") {
+			return "" // tag push
+		}
+		return branch
+	case "create", "delete":
+		if str(payload, "ref_type") == "branch" {
+			return str(payload, "ref")
+		}
+	case "pull_request", "pull_request_review", "pull_request_review_comment":
+		if pr := obj("pull_request"); pr != nil {
+			base, _ := pr["base"].(map[string]interface{})
+			return str(base, "ref")
+		}
+	case "workflow_run", "check_suite":
+		if m := obj(event); m != nil {
+			return str(m, "head_branch")
+		}
+	}
+	return ""
+}
+
+func (h *Handler) handleGitHubWebhook(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodyBytes))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Failed to read body"})
+		return
+	}
+	event := c.GetHeader("X-GitHub-Event")
+	if event == "" {
+		c.JSON(400, gin.H{"error": "Missing X-GitHub-Event header"})
+		return
+	}
+
+	// Hooks configured with the form content type send the JSON in a
+	// "payload" field; the signature still covers the raw body.
+	jsonBody := body
+	if strings.HasPrefix(strings.ToLower(c.ContentType()), "application/x-www-form-urlencoded") {
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			jsonBody = []byte(values.Get("payload"))
+		}
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(jsonBody, &payload); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid JSON"})
+		return
+	}
+	action, _ := payload["action"].(string)
+	eventType := githubEventType(event, action)
+	delivery := c.GetHeader("X-GitHub-Delivery")
+
+	connectionID, config, ok := h.webhookConnection(c, "github")
+	if !ok {
+		return
+	}
+
+	eventID := uuid.New().String()
+	payload["webhook"] = map[string]interface{}{
+		"source":        "github",
+		"connection_id": connectionID,
+		"event":         event,
+		"action":        action,
+		"delivery_id":   delivery,
+		"event_id":      eventID,
+		"received_at":   time.Now().Format(time.RFC3339),
+	}
+	stored, _ := json.Marshal(payload)
+
+	verification := verifyGitHubWebhook(c, config, body)
+	if verification != VerificationVerified {
+		h.storeWebhookEvent(inboundEvent{
+			ID: eventID, Source: "github", EventType: eventType, Payload: stored, Verification: verification,
+		})
+		log.Printf("🚫 Rejected GitHub webhook (%s): %s (event_id=%s)", verification, eventType, eventID)
+		c.JSON(401, gin.H{"error": "Webhook signature " + verification})
+		return
+	}
+	if event == "ping" {
+		c.JSON(200, gin.H{"status": "pong"})
+		return
+	}
+
+	duplicateOf, err := h.storeWebhookEvent(inboundEvent{
+		ID: eventID, Source: "github", EventType: eventType, Payload: stored,
+		Verification: verification, DedupKey: dedupKey("delivery", delivery),
+	})
+	if err != nil {
+		log.Printf("Failed to store webhook event: %v", err)
+		c.JSON(500, gin.H{"error": "Failed to store webhook event"})
+		return
+	}
+	if duplicateOf != "" {
+		log.Printf("♻️ Duplicate GitHub delivery %s ignored (event_id=%s)", delivery, duplicateOf)
+		c.JSON(200, gin.H{"status": "duplicate", "event_id": duplicateOf})
+		return
+	}
+
+	log.Printf("📩 GitHub webhook received: %s (event_id=%s, delivery=%s)", eventType, eventID, delivery)
+	go func() {
+		h.recordMatchResults(eventID, h.matchGitHubEvent(eventID, eventType, stored, "", runOptions{}))
+	}()
+	c.JSON(200, gin.H{"status": "received", "event_id": eventID})
+}
+
+// matchGitHubEvent runs trigger matching for a stored GitHub event.
+func (h *Handler) matchGitHubEvent(eventID, eventType string, payload []byte, workflowID string, opts runOptions) []TriggerMatch {
+	var parsed map[string]interface{}
+	json.Unmarshal(payload, &parsed)
+	event, action, _ := strings.Cut(eventType, ".")
+	return h.dispatchEvent(eventID, "github_webhook", payload, workflowID, opts, githubMatcher(event, action, parsed))
+}
+
+// githubMatcher applies a github_webhook node's event, action, repository
+// and branch filters. Each is a comma-separated list of values or globs.
+func githubMatcher(event, action string, parsed map[string]interface{}) triggerMatcher {
+	var repo string
+	if r, ok := parsed["repository"].(map[string]interface{}); ok {
+		repo, _ = r["full_name"].(string)
+	}
+	branch := githubBranch(event, parsed)
+
+	return func(data map[string]interface{}) (bool, string) {
+		for _, chk := range [][2]string{{"event", event}, {"action", action}, {"repository", repo}, {"branch", branch}} {
+			filter, _ := data[chk[0]].(string)
+			if !matchesFilterList(filter, chk[1]) {
+				return false, fmt.Sprintf("%s %q does not match filter %q", chk[0], chk[1], filter)
+			}
+		}
+		return true, ""
+	}
+}
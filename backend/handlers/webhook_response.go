@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== Synchronous Webhook Responses ====================
+
+const (
+	defaultWebhookResponseTimeout = 30 * time.Second
+	maxWebhookResponseTimeout     = 5 * time.Minute
+)
+
+// webhookResponse is what a webhook_response node sends back to the caller
+// still waiting on the webhook request that started the run.
+type webhookResponse struct {
+	StatusCode int               `json:"status_code"`
+	Headers    map[string]string `json:"headers"`
+	Body       interface{}       `json:"body"`
+}
+
+// responseRegistry holds the channels of webhook callers waiting for a
+// run's response, keyed by run ID.
+type responseRegistry struct {
+	mu      sync.Mutex
+	waiting map[string]chan webhookResponse
+}
+
+func newResponseRegistry() *responseRegistry {
+	return &responseRegistry{waiting: map[string]chan webhookResponse{}}
+}
+
+func (r *responseRegistry) register(runID string, ch chan webhookResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.waiting[runID] = ch
+}
+
+func (r *responseRegistry) forget(runID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.waiting, runID)
+}
+
+// deliver hands the response to the caller waiting on the run, if any. Only
+// the first response for a run is delivered.
+func (r *responseRegistry) deliver(runID string, resp webhookResponse) {
+	r.mu.Lock()
+	ch, ok := r.waiting[runID]
+	delete(r.waiting, runID)
+	r.mu.Unlock()
+	if ok {
+		ch <- resp
+	}
+}
+
+// workflowHasNode reports whether the workflow contains a node of the type.
+func workflowHasNode(nodesJSON json.RawMessage, nodeType string) bool {
+	var nodes []struct {
+		Type string `json:"type"`
+	}
+	json.Unmarshal(nodesJSON, &nodes)
+	for _, n := range nodes {
+		if n.Type == nodeType {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookResponseTimeout reads the webhook node's response_timeout (seconds).
+func webhookResponseTimeout(data map[string]interface{}) time.Duration {
+	var secs float64
+	switch v := data["response_timeout"].(type) {
+	case float64:
+		secs = v
+	case string:
+		secs, _ = strconv.ParseFloat(v, 64)
+	}
+	if secs <= 0 {
+		return defaultWebhookResponseTimeout
+	}
+	if d := time.Duration(secs * float64(time.Second)); d < maxWebhookResponseTimeout {
+		return d
+	}
+	return maxWebhookResponseTimeout
+}
+
+// writeWebhookResponse sends a workflow's response to the webhook caller.
+// String bodies are sent as-is (text/plain unless a Content-Type header is
+// set); anything else is sent as JSON.
+func writeWebhookResponse(c *gin.Context, resp webhookResponse) {
+	contentType := ""
+	for k, v := range resp.Headers {
+		if strings.EqualFold(k, ContentTypeHeader) {
+			contentType = v
+			continue
+		}
+		c.Header(k, v)
+	}
+	if s, ok := resp.Body.(string); ok {
+		if contentType == "" {
+			contentType = "text/plain; charset=utf-8"
+		}
+		c.Data(resp.StatusCode, contentType, []byte(s))
+		return
+	}
+	body, _ := json.Marshal(resp.Body)
+	if contentType == "" {
+		contentType = ContentTypeJSON
+	}
+	c.Data(resp.StatusCode, contentType, body)
+}
+
+// executeWebhookResponse builds the response described by a webhook_response
+// node: status_code, headers (a JSON object) and body, all templated against
+// the input. A body that is valid JSON after templating is sent as JSON; an
+// empty body sends the node's input. The engine delivers the result to the
+// waiting caller; in a dry run it is simply returned.
+func executeWebhookResponse(data map[string]interface{}, input json.RawMessage) (json.RawMessage, string) {
+	var inputMap map[string]interface{}
+	json.Unmarshal(input, &inputMap)
+
+	resp := webhookResponse{StatusCode: 200, Headers: map[string]string{}}
+	switch v := data["status_code"].(type) {
+	case float64:
+		resp.StatusCode = int(v)
+	case string:
+		if v = strings.TrimSpace(templateReplace(v, inputMap)); v != "" {
+			code, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Sprintf("Respond to Webhook: invalid status code %q", v)
+			}
+			resp.StatusCode = code
+		}
+	}
+	if resp.StatusCode < 100 || resp.StatusCode > 599 {
+		return nil, fmt.Sprintf("Respond to Webhook: invalid status code %d", resp.StatusCode)
+	}
+
+	if headers, _ := data["headers"].(string); strings.TrimSpace(headers) != "" {
+		var parsed map[string]interface{}
+		if err := json.Unmarshal([]byte(templateReplace(headers, inputMap)), &parsed); err != nil {
+			return nil, fmt.Sprintf("Respond to Webhook: headers must be a JSON object: %v", err)
+		}
+		for k, v := range parsed {
+			resp.Headers[k] = fmt.Sprint(v)
+		}
+	}
+
+	body, _ := data["body"].(string)
+	if strings.TrimSpace(body) == "" {
+		var v interface{}
+		json.Unmarshal(input, &v)
+		resp.Body = v
+	} else {
+		body = templateReplace(body, inputMap)
+		var v interface{}
+		if json.Unmarshal([]byte(body), &v) == nil {
+			resp.Body = v
+		} else {
+			resp.Body = body
+		}
+	}
+
+	out, _ := json.Marshal(resp)
+	return out, ""
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ==================== Slack Receiver ====================
+
+// slackEventTypes are the Events API callbacks that can trigger workflows.
+var slackEventTypes = map[string]bool{"message": true, "reaction_added": true, "app_mention": true}
+
+// slackIgnoredSubtypes are message subtypes that never trigger: our own bot
+// posts (to avoid loops) and edits or deletions of earlier messages.
+var slackIgnoredSubtypes = map[string]bool{"bot_message": true, "message_changed": true, "message_deleted": true}
+
+// verifySlackRequest checks the request against the signing_secret of its
+// slack connection. Requests are rejected until a signing secret is set.
+func verifySlackRequest(c *gin.Context, config map[string]interface{}, body []byte) string {
+	secret, _ := config["signing_secret"].(string)
+	if secret == "" {
+		return VerificationNotConfigured
+	}
+	sig := c.GetHeader("X-Slack-Signature")
+	if sig == "" {
+		return VerificationUnsigned
+	}
+	if verifySlackSignature(secret, body, c.GetHeader("X-Slack-Request-Timestamp"), sig) {
+		return VerificationVerified
+	}
+	return VerificationInvalid
+}
+
+// slackEventContext is the "slack" object added to run input: where the
+// event happened and where a reply should go. thread_ts is the thread to
+// reply in, which for a top-level message is the message itself.
+func slackEventContext(teamID string, event map[string]interface{}) map[string]interface{} {
+	str := func(m map[string]interface{}, key string) string {
+		s, _ := m[key].(string)
+		return s
+	}
+	evType := str(event, "type")
+	ctx := map[string]interface{}{
+		"type":    evType,
+		"team_id": teamID,
+		"user":    str(event, "user"),
+		"text":    str(event, "text"),
+		"channel": str(event, "channel"),
+		"ts":      str(event, "ts"),
+	}
+	threadTS := str(event, "thread_ts")
+	if evType == "reaction_added" {
+		item, _ := event["item"].(map[string]interface{})
+		ctx["channel"] = str(item, "channel")
+		ctx["ts"] = str(item, "ts")
+		ctx["reaction"] = str(event, "reaction")
+		ctx["item_user"] = str(event, "item_user")
+	}
+	if threadTS == "" {
+		threadTS, _ = ctx["ts"].(string)
+	}
+	ctx["thread_ts"] = threadTS
+	return ctx
+}
+
+// handleSlackEvents receives Events API callbacks.
+func (h *Handler) handleSlackEvents(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodyBytes))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Failed to read body"})
+		return
+	}
+	var envelope map[string]interface{}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid JSON"})
+		return
+	}
+	envType, _ := envelope["type"].(string)
+	event, _ := envelope["event"].(map[string]interface{})
+	eventType, _ := event["type"].(string)
+	if eventType == "" {
+		eventType = envType
+	}
+
+	connectionID, config, ok := h.webhookConnection(c, "slack")
+	if !ok {
+		return
+	}
+	verification := verifySlackRequest(c, config, body)
+	if verification != VerificationVerified {
+		eventID := uuid.New().String()
+		h.storeWebhookEvent(inboundEvent{ID: eventID, Source: "slack", EventType: eventType, Payload: body, Verification: verification})
+		log.Printf("🚫 Rejected Slack request (%s): %s (event_id=%s)", verification, eventType, eventID)
+		c.JSON(401, gin.H{"error": "Webhook signature " + verification})
+		return
+	}
+
+	if envType == "url_verification" {
+		c.JSON(200, gin.H{"challenge": envelope["challenge"]})
+		return
+	}
+	if envType != "event_callback" || !slackEventTypes[eventType] {
+		c.JSON(200, gin.H{"status": "ignored"})
+		return
+	}
+	if subtype, _ := event["subtype"].(string); slackIgnoredSubtypes[subtype] || event["bot_id"] != nil {
+		c.JSON(200, gin.H{"status": "ignored"})
+		return
+	}
+
+	teamID, _ := envelope["team_id"].(string)
+	slackCtx := slackEventContext(teamID, event)
+	slackCtx["connection_id"] = connectionID
+	envelope["slack"] = slackCtx
+	payload, _ := json.Marshal(envelope)
+	slackEventID, _ := envelope["event_id"].(string)
+
+	eventID := uuid.New().String()
+	duplicateOf, err := h.storeWebhookEvent(inboundEvent{
+		ID: eventID, Source: "slack", EventType: eventType, Payload: payload,
+		Verification: verification, DedupKey: dedupKey("event", slackEventID),
+	})
+	if err != nil {
+		log.Printf("Failed to store webhook event: %v", err)
+		c.JSON(500, gin.H{"error": "Failed to store webhook event"})
+		return
+	}
+	if duplicateOf != "" {
+		c.JSON(200, gin.H{"status": "duplicate", "event_id": duplicateOf})
+		return
+	}
+
+	log.Printf("📩 Slack event received: %s (event_id=%s)", eventType, eventID)
+	go func() {
+		h.recordMatchResults(eventID, h.matchSlackEvent(eventID, payload, "", runOptions{}))
+	}()
+	c.JSON(200, gin.H{"status": "received", "event_id": eventID})
+}
+
+// handleSlackCommand receives slash commands. Matching runs inline so the
+// ephemeral reply can say whether a workflow started; Slack allows 3s.
+func (h *Handler) handleSlackCommand(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodyBytes))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Failed to read body"})
+		return
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil || values.Get("command") == "" {
+		c.JSON(400, gin.H{"error": "Invalid slash command payload"})
+		return
+	}
+	form := flattenValues(values)
+
+	connectionID, config, ok := h.webhookConnection(c, "slack")
+	if !ok {
+		return
+	}
+	verification := verifySlackRequest(c, config, body)
+	if verification != VerificationVerified {
+		eventID := uuid.New().String()
+		stored, _ := json.Marshal(form)
+		h.storeWebhookEvent(inboundEvent{ID: eventID, Source: "slack", EventType: "slash_command", Payload: stored, Verification: verification})
+		log.Printf("🚫 Rejected Slack command (%s): %s (event_id=%s)", verification, values.Get("command"), eventID)
+		c.JSON(401, gin.H{"error": "Webhook signature " + verification})
+		return
+	}
+
+	command := values.Get("command")
+	form["slack"] = map[string]interface{}{
+		"type":          "slash_command",
+		"team_id":       values.Get("team_id"),
+		"user":          values.Get("user_id"),
+		"user_name":     values.Get("user_name"),
+		"text":          values.Get("text"),
+		"channel":       values.Get("channel_id"),
+		"channel_name":  values.Get("channel_name"),
+		"command":       command,
+		"response_url":  values.Get("response_url"),
+		"connection_id": connectionID,
+		"ts":            "",
+		"thread_ts":     "",
+	}
+	payload, _ := json.Marshal(form)
+
+	eventID := uuid.New().String()
+	duplicateOf, err := h.storeWebhookEvent(inboundEvent{
+		ID: eventID, Source: "slack", EventType: "slash_command", Payload: payload,
+		Verification: verification, DedupKey: dedupKey("command", values.Get("trigger_id")),
+	})
+	if err != nil {
+		log.Printf("Failed to store webhook event: %v", err)
+		c.JSON(500, gin.H{"error": "Failed to store webhook event"})
+		return
+	}
+	if duplicateOf != "" {
+		c.JSON(200, gin.H{"response_type": "ephemeral", "text": "Already received."})
+		return
+	}
+
+	log.Printf("📩 Slack command received: %s %s (event_id=%s)", command, values.Get("text"), eventID)
+	results := h.matchSlackEvent(eventID, payload, "", runOptions{})
+	h.recordMatchResults(eventID, results)
+
+	started := 0
+	for _, m := range results {
+		if m.Matched {
+			started++
+		}
+	}
+	text := fmt.Sprintf("No workflow handles %s.", command)
+	if started == 1 {
+		text = "Started 1 workflow."
+	} else if started > 1 {
+		text = fmt.Sprintf("Started %d workflows.", started)
+	}
+	c.JSON(200, gin.H{"response_type": "ephemeral", "text": text})
+}
+
+// matchSlackEvent runs trigger matching for a stored Slack event or command.
+func (h *Handler) matchSlackEvent(eventID string, payload []byte, workflowID string, opts runOptions) []TriggerMatch {
+	var stored struct {
+		Slack map[string]interface{} `json:"slack"`
+	}
+	json.Unmarshal(payload, &stored)
+	return h.dispatchEvent(eventID, "slack_trigger", payload, workflowID, opts, slackMatcher(stored.Slack))
+}
+
+// slackMatcher applies a slack_trigger node's event type, channel and
+// command filters. Channels match by ID or by name, with or without '#'.
+func slackMatcher(ctx map[string]interface{}) triggerMatcher {
+	str := func(key string) string {
+		s, _ := ctx[key].(string)
+		return s
+	}
+	evType, channel, channelName, command := str("type"), str("channel"), str("channel_name"), str("command")
+
+	return func(data map[string]interface{}) (bool, string) {
+		if filter, _ := data["events"].(string); !matchesFilterList(filter, evType) {
+			return false, fmt.Sprintf("event %q does not match filter %q", evType, filter)
+		}
+		if filter, _ := data["channel"].(string); filter != "" {
+			filter = strings.ReplaceAll(filter, "#", "")
+			if !matchesFilterList(filter, channel) && (channelName == "" || !matchesFilterList(filter, channelName)) {
+				return false, fmt.Sprintf("channel %q does not match filter %q", channel, filter)
+			}
+		}
+		if filter, _ := data["command"].(string); filter != "" {
+			if command == "" {
+				return false, "command filter set but event is not a slash command"
+			}
+			if !matchesFilterList(filter, command) {
+				return false, fmt.Sprintf("command %q does not match filter %q", command, filter)
+			}
+		}
+		return true, ""
+	}
+}
+
+// postSlackResponseURL replies to a slash command through its response_url,
+// which works even where the bot is not a channel member.
+func postSlackResponseURL(responseURL, text string) error {
+	body, _ := json.Marshal(map[string]interface{}{"response_type": "in_channel", "text": text})
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Post(responseURL, ContentTypeJSON, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("response_url returned %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -1,116 +1,86 @@
 package handlers
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-// ==================== Jira Webhook Registration ====================
-
-func (h *Handler) registerJiraWebhook(c *gin.Context) {
-	var req struct {
-		JiraDomain   string   `json:"jira_domain"`
-		JiraEmail    string   `json:"jira_email"`
-		JiraAPIToken string   `json:"jira_api_token"`
-		WebhookURL   string   `json:"webhook_url"`
-		Events       []string `json:"events"`
-		JQLFilter    string   `json:"jql_filter"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request body"})
-		return
-	}
-	if req.JiraDomain == "" || req.JiraEmail == "" || req.JiraAPIToken == "" || req.WebhookURL == "" {
-		c.JSON(400, gin.H{"error": "jira_domain, jira_email, jira_api_token, and webhook_url are required"})
-		return
-	}
-	if len(req.Events) == 0 {
-		req.Events = []string{"jira:issue_created", "jira:issue_updated", "jira:issue_deleted"}
-	}
+// ==================== Jira Webhook Receiver ====================
 
-	webhookName := fmt.Sprintf("workflow-platform-%s", uuid.New().String()[:8])
-	jiraPayload := map[string]interface{}{
-		"name":   webhookName,
-		"url":    req.WebhookURL,
-		"events": req.Events,
-	}
-	if req.JQLFilter != "" {
-		jiraPayload["filters"] = map[string]interface{}{
-			"issue-related-events-section": req.JQLFilter,
-		}
-	}
+// Actions taken on an inbound delivery after verification.
+const (
+	webhookProcess    = "process"
+	webhookQuarantine = "quarantine"
+	webhookReject     = "reject"
+)
 
-	payloadBytes, _ := json.Marshal(jiraPayload)
-	url := fmt.Sprintf("https://%s/rest/webhooks/1.0/webhook", req.JiraDomain)
-	httpReq, err := http.NewRequest("POST", url, bytes.NewReader(payloadBytes))
-	if err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to build request: %v", err)})
-		return
-	}
-	httpReq.SetBasicAuth(req.JiraEmail, req.JiraAPIToken)
-	httpReq.Header.Set(ContentTypeHeader, ContentTypeJSON)
-	httpReq.Header.Set("Accept", ContentTypeJSON)
+// webhookConnection loads the stored config of the connection a delivery is
+// addressed to: the :connection path parameter, or the type's default
+// connection on the plain route. It answers 404 and returns false when the
+// path names a connection that does not exist.
+func (h *Handler) webhookConnection(c *gin.Context, iType string) (string, map[string]interface{}, bool) {
+	connectionID := c.Param("connection")
+	_, config, err := h.storedConnectionConfig(iType, connectionID)
+	if err != nil && connectionID != "" {
+		c.JSON(404, gin.H{"error": "Connection not found"})
+		return "", nil, false
+	}
+	return connectionID, config, true
+}
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to call Jira API: %v", err)})
-		return
-	}
-	defer resp.Body.Close()
-	respBody, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode >= 400 {
-		log.Printf("❌ Jira webhook registration failed (%d): %s", resp.StatusCode, string(respBody))
-		c.JSON(resp.StatusCode, gin.H{
-			"error":   fmt.Sprintf("Jira API returned %d", resp.StatusCode),
-			"details": string(respBody),
-		})
-		return
+// verifyJiraWebhook checks a delivery against the secrets on its jira
+// connection: the HMAC in X-Hub-Signature when webhook_secret is set, else a
+// static webhook_token sent in a header (webhook_token_header, default
+// X-Webhook-Token) or the ?token= query param. Unsigned deliveries are
+// rejected unless unsigned_policy is "quarantine", and so is every delivery
+// while neither secret is configured.
+func verifyJiraWebhook(c *gin.Context, config map[string]interface{}, body []byte) (string, string) {
+	unverified := webhookReject
+	if policy, _ := config["unsigned_policy"].(string); policy == "quarantine" {
+		unverified = webhookQuarantine
+	}
+	secret, _ := config["webhook_secret"].(string)
+	token, _ := config["webhook_token"].(string)
+	if secret == "" && token == "" {
+		return VerificationNotConfigured, unverified
+	}
+
+	if sig := c.GetHeader("X-Hub-Signature"); sig != "" && secret != "" {
+		if verifyPrefixedHMAC(secret, body, sig) {
+			return VerificationVerified, webhookProcess
+		}
+		return VerificationInvalid, webhookReject
 	}
 
-	var jiraResp map[string]interface{}
-	json.Unmarshal(respBody, &jiraResp)
-	webhookID := ""
-	if self, ok := jiraResp["self"].(string); ok {
-		parts := strings.Split(self, "/")
-		if len(parts) > 0 {
-			webhookID = parts[len(parts)-1]
+	if token != "" {
+		tokenHeader, _ := config["webhook_token_header"].(string)
+		if tokenHeader == "" {
+			tokenHeader = "X-Webhook-Token"
+		}
+		got := c.GetHeader(tokenHeader)
+		if got == "" {
+			got = c.Query("token")
+		}
+		if got != "" {
+			if constantTimeEqual(got, token) {
+				return VerificationVerified, webhookProcess
+			}
+			return VerificationInvalid, webhookReject
 		}
 	}
 
-	log.Printf("✅ Jira webhook registered: name=%s, id=%s, url=%s", webhookName, webhookID, req.WebhookURL)
-	c.JSON(201, gin.H{
-		"message":    "Webhook registered on Jira successfully",
-		"name":       webhookName,
-		"webhook_id": webhookID,
-		"events":     req.Events,
-	})
+	return VerificationUnsigned, unverified
 }
 
-// ==================== Jira Webhook Receiver ====================
-
 func (h *Handler) handleJiraWebhook(c *gin.Context) {
-	triggeredByUser := c.Query("triggeredByUser")
-	if triggeredByUser != "" {
-		ownAccountID := h.getJiraAccountID()
-		if ownAccountID != "" && ownAccountID == triggeredByUser {
-			log.Printf("⏭️ Skipping Jira webhook triggered by own account (%s) — loop prevention", triggeredByUser)
-			c.JSON(200, gin.H{"status": "skipped", "reason": "triggered by own integration account"})
-			return
-		}
-	}
-
-	body, err := io.ReadAll(c.Request.Body)
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodyBytes))
 	if err != nil {
 		c.JSON(400, gin.H{"error": "Failed to read body"})
 		return
@@ -127,86 +97,91 @@ func (h *Handler) handleJiraWebhook(c *gin.Context) {
 		eventType = we
 	}
 
+	_, config, ok := h.webhookConnection(c, "jira")
+	if !ok {
+		return
+	}
+	verification, action := verifyJiraWebhook(c, config, body)
+	if action != webhookProcess {
+		eventID := uuid.New().String()
+		_, err := h.storeWebhookEvent(inboundEvent{
+			ID: eventID, Source: "jira", EventType: eventType, Payload: body,
+			Verification: verification, Quarantined: action == webhookQuarantine,
+		})
+		if action == webhookQuarantine {
+			if err != nil {
+				log.Printf("Failed to store webhook event: %v", err)
+				c.JSON(500, gin.H{"error": "Failed to store webhook event"})
+				return
+			}
+			log.Printf("🔒 Quarantined %s Jira webhook: %s (event_id=%s)", verification, eventType, eventID)
+			c.JSON(202, gin.H{"status": "quarantined", "event_id": eventID})
+			return
+		}
+		log.Printf("🚫 Rejected Jira webhook (%s): %s (event_id=%s)", verification, eventType, eventID)
+		c.JSON(401, gin.H{"error": "Webhook signature " + verification})
+		return
+	}
+
+	// Loop prevention: prefer the account in the (now verified) payload over
+	// the triggeredByUser query param.
+	triggeredByUser := c.Query("triggeredByUser")
+	if user, ok := payload["user"].(map[string]interface{}); ok {
+		if accountID, _ := user["accountId"].(string); accountID != "" {
+			triggeredByUser = accountID
+		}
+	}
+	if triggeredByUser != "" {
+		if h.isOwnJiraAccount(triggeredByUser) {
+			log.Printf("⏭️ Skipping Jira webhook triggered by own account (%s) — loop prevention", triggeredByUser)
+			c.JSON(200, gin.H{"status": "skipped", "reason": "triggered by own integration account"})
+			return
+		}
+	}
+
 	eventID := uuid.New().String()
-	_, err = h.db.Exec(
-		"INSERT INTO webhook_events (id, source, event_type, payload) VALUES (?, 'jira', ?, ?)",
-		eventID, eventType, body,
-	)
+	duplicateOf, err := h.storeWebhookEvent(inboundEvent{
+		ID: eventID, Source: "jira", EventType: eventType, Payload: body,
+		Verification: verification, DedupKey: jiraDedupKey(c.Request.Header, payload),
+	})
 	if err != nil {
 		log.Printf("Failed to store webhook event: %v", err)
+		c.JSON(500, gin.H{"error": "Failed to store webhook event"})
+		return
+	}
+	if duplicateOf != "" {
+		log.Printf("♻️ Duplicate Jira webhook delivery ignored: %s (event_id=%s)", eventType, duplicateOf)
+		c.JSON(200, gin.H{"status": "duplicate", "event_id": duplicateOf})
+		return
 	}
 
-	log.Printf("📩 Jira webhook received: %s (event_id=%s)", eventType, eventID)
+	log.Printf("📩 Jira webhook received: %s (event_id=%s, verification=%s)", eventType, eventID, verification)
 	go h.processJiraWebhookTrigger(eventID, eventType, body)
 	c.JSON(200, gin.H{"status": "received", "event_id": eventID})
 }
 
 func (h *Handler) processJiraWebhookTrigger(eventID, eventType string, payload []byte) {
-	rows, err := h.db.Query("SELECT id, name, nodes, edges FROM workflows WHERE status = 'active'")
-	if err != nil {
-		log.Printf("Failed to query workflows for webhook trigger: %v", err)
-		return
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var w Workflow
-		if err := rows.Scan(&w.ID, &w.Name, &w.Nodes, &w.Edges); err != nil {
-			continue
-		}
-		if h.triggerWorkflowForJiraWebhook(&w, eventID, eventType, payload) {
-			break
-		}
-	}
+	h.recordMatchResults(eventID, h.matchJiraEvent(eventID, eventType, payload, "", runOptions{}))
 }
 
-func (h *Handler) triggerWorkflowForJiraWebhook(w *Workflow, eventID, eventType string, payload []byte) bool {
-	var nodes []map[string]interface{}
-	if err := json.Unmarshal(w.Nodes, &nodes); err != nil {
-		return false
-	}
-
-	for _, node := range nodes {
-		nodeType, _ := node["type"].(string)
-		if nodeType != "jira_webhook" {
-			continue
-		}
+// matchJiraEvent runs trigger matching for a Jira event against every active
+// workflow, or only against workflowID (whatever its status) when set.
+func (h *Handler) matchJiraEvent(eventID, eventType string, payload []byte, workflowID string, opts runOptions) []TriggerMatch {
+	var parsed map[string]interface{}
+	json.Unmarshal(payload, &parsed)
+	return h.dispatchEvent(eventID, "jira_webhook", payload, workflowID, opts, jiraMatcher(eventType, parsed))
+}
 
-		data, _ := node["data"].(map[string]interface{})
+// jiraMatcher applies a jira_webhook node's event_filter and jql_filter.
+func jiraMatcher(eventType string, parsed map[string]interface{}) triggerMatcher {
+	return func(data map[string]interface{}) (bool, string) {
 		filterEvent, _ := data["event_filter"].(string)
 		if filterEvent != "" && filterEvent != eventType {
-			continue
+			return false, fmt.Sprintf("event %s does not match event_filter %s", eventType, filterEvent)
 		}
-
-		log.Printf("🚀 Triggering workflow '%s' (id=%s) from Jira webhook: %s", w.Name, w.ID, eventType)
-
-		runID := uuid.New().String()
-		h.db.Exec(
-			"INSERT INTO workflow_runs (id, workflow_id, status, input) VALUES (?, ?, 'running', ?)",
-			runID, w.ID, payload,
-		)
-		h.db.Exec("UPDATE webhook_events SET processed = TRUE, workflow_run_id = ? WHERE id = ?", runID, eventID)
-		go h.executeWorkflow(runID, *w, payload)
-		return true
-	}
-	return false
-}
-
-func (h *Handler) getWebhookEvents(c *gin.Context) {
-	rows, err := h.db.Query("SELECT id, source, event_type, payload, processed, workflow_run_id, created_at FROM webhook_events ORDER BY created_at DESC LIMIT 50")
-	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
-		return
-	}
-	defer rows.Close()
-
-	var events []WebhookEvent
-	for rows.Next() {
-		var e WebhookEvent
-		if err := rows.Scan(&e.ID, &e.Source, &e.EventType, &e.Payload, &e.Processed, &e.WorkflowRunID, &e.CreatedAt); err != nil {
-			continue
+		if jql, _ := data["jql_filter"].(string); strings.TrimSpace(jql) != "" {
+			return matchJQL(jql, parsed)
 		}
-		events = append(events, e)
+		return true, ""
 	}
-	c.JSON(200, events)
 }
@@ -3,6 +3,8 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"log"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -83,6 +85,10 @@ func (h *Handler) updateWorkflow(c *gin.Context) {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
+	if msg := validateTriggerFilters(req.Nodes); msg != "" {
+		c.JSON(400, gin.H{"error": msg})
+		return
+	}
 	_, err := h.db.Exec(
 		"UPDATE workflows SET name = ?, description = ?, nodes = ?, edges = ?, status = ? WHERE id = ?",
 		req.Name, req.Description, req.Nodes, req.Edges, req.Status, id,
@@ -92,15 +98,75 @@ func (h *Handler) updateWorkflow(c *gin.Context) {
 		return
 	}
 
-	h.syncTriggerFromStartNode(id, req.Nodes)
+	h.syncTriggersFromNodes(id, req.Nodes)
+	h.refreshWorkflowSchedule(id)
 
 	c.JSON(200, gin.H{"message": "Workflow updated"})
 }
 
-// syncTriggerFromStartNode extracts trigger_type and cron_schedule from the
-// start node's data and syncs them to the workflow-level columns.
-func (h *Handler) syncTriggerFromStartNode(workflowID string, nodesJSON json.RawMessage) {
+// webhookTriggerNodes lists the node types that start a workflow from an
+// inbound event, with the name given to their derived trigger.
+var webhookTriggerNodes = map[string]string{
+	"jira_webhook":   "Jira webhook",
+	"webhook":        "Webhook",
+	"github_webhook": "GitHub webhook",
+	"slack_trigger":  "Slack trigger",
+}
+
+// isTriggerNode reports whether a node type starts a workflow run.
+func isTriggerNode(nodeType string) bool {
+	_, ok := webhookTriggerNodes[nodeType]
+	return ok || nodeType == "start"
+}
+
+// validateTriggerFilters checks the filters and input mapping configured on
+// trigger nodes so a typo is reported on save rather than silently skipping
+// every event.
+func validateTriggerFilters(nodesJSON json.RawMessage) string {
+	var nodes []struct {
+		ID   string                 `json:"id"`
+		Type string                 `json:"type"`
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(nodesJSON, &nodes); err != nil {
+		return ""
+	}
+	for _, n := range nodes {
+		if !isTriggerNode(n.Type) {
+			continue
+		}
+		if expr, _ := n.Data["filter_expression"].(string); strings.TrimSpace(expr) != "" {
+			if _, err := parseExpr(expr); err != nil {
+				return fmt.Sprintf("Node %s: invalid filter expression: %v", n.ID, err)
+			}
+		}
+		if _, err := parseInputMapping(n.Data); err != nil {
+			return fmt.Sprintf("Node %s: invalid input mapping: %v", n.ID, err)
+		}
+		if raw, _ := n.Data["schedule_input"].(string); n.Type == "start" && strings.TrimSpace(raw) != "" {
+			var obj map[string]interface{}
+			if err := json.Unmarshal([]byte(raw), &obj); err != nil || obj == nil {
+				return fmt.Sprintf("Node %s: schedule input must be a JSON object", n.ID)
+			}
+		}
+		if n.Type != "jira_webhook" {
+			continue
+		}
+		if jql, _ := n.Data["jql_filter"].(string); strings.TrimSpace(jql) != "" {
+			if _, err := parseJQL(jql); err != nil {
+				return fmt.Sprintf("Node %s: invalid JQL filter: %v", n.ID, err)
+			}
+		}
+	}
+	return ""
+}
+
+// syncTriggersFromNodes derives workflow_triggers rows from the canvas: a
+// schedule trigger for a start node with a cron schedule and a webhook
+// trigger for every webhook trigger node. Rows for removed nodes are deleted.
+func (h *Handler) syncTriggersFromNodes(workflowID string, nodesJSON json.RawMessage) {
 	var nodes []struct {
+		ID   string                 `json:"id"`
 		Type string                 `json:"type"`
 		Data map[string]interface{} `json:"data"`
 	}
@@ -110,37 +176,53 @@ func (h *Handler) syncTriggerFromStartNode(workflowID string, nodesJSON json.Raw
 
 	hasWebhookNode := false
 	for _, n := range nodes {
-		if n.Type == "jira_webhook" {
+		if _, ok := webhookTriggerNodes[n.Type]; ok {
 			hasWebhookNode = true
 			break
 		}
 	}
 
+	keep := []interface{}{workflowID}
 	for _, n := range nodes {
-		if n.Type == "start" {
-			triggerType, _ := n.Data["trigger_type"].(string)
-			cronSchedule, _ := n.Data["cron_schedule"].(string)
-
-			if triggerType == "" {
-				if hasWebhookNode {
-					triggerType = "trigger"
-				} else {
-					triggerType = "schedule"
-				}
-			}
-
-			var cronPtr *string
-			if triggerType == "schedule" && cronSchedule != "" {
-				cronPtr = &cronSchedule
+		if n.ID == "" {
+			continue
+		}
+		if name, ok := webhookTriggerNodes[n.Type]; ok {
+			h.upsertNodeTrigger(workflowID, n.ID, TriggerTypeWebhook, name, nil, nil, false)
+			if n.Type == "webhook" {
+				h.ensureWebhookToken(workflowID, n.ID)
 			}
+			keep = append(keep, n.ID)
+			continue
+		}
+		if n.Type != "start" {
+			continue
+		}
 
-			h.db.Exec(
-				"UPDATE workflows SET trigger_type = ?, cron_schedule = ? WHERE id = ?",
-				triggerType, cronPtr, workflowID,
-			)
-			return
+		triggerType, _ := n.Data["trigger_type"].(string)
+		cronSchedule, _ := n.Data["cron_schedule"].(string)
+		triggerType = normaliseTriggerType(triggerType)
+		if triggerType == "" && !hasWebhookNode {
+			triggerType = TriggerTypeSchedule
+		}
+		if triggerType != TriggerTypeSchedule || cronSchedule == "" {
+			continue
 		}
+		if _, err := parseCronSchedule(cronSchedule); err != nil {
+			log.Printf("⚠️ Start node on workflow %s has invalid cron schedule %q: %v", workflowID, cronSchedule, err)
+			continue
+		}
+		h.upsertNodeTrigger(workflowID, n.ID, TriggerTypeSchedule, "Schedule", &cronSchedule, startNodeScheduleInput(n.Data), true)
+		keep = append(keep, n.ID)
+	}
+
+	query := "DELETE FROM workflow_triggers WHERE workflow_id = ? AND node_id IS NOT NULL"
+	if len(keep) > 1 {
+		query += " AND node_id NOT IN (?" + strings.Repeat(", ?", len(keep)-2) + ")"
 	}
+	h.db.Exec(query, keep...)
+
+	h.syncWorkflowTriggerSummary(workflowID)
 }
 
 func (h *Handler) deleteWorkflow(c *gin.Context) {
@@ -150,9 +232,13 @@ func (h *Handler) deleteWorkflow(c *gin.Context) {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
+	h.scheduler.removeWorkflow(id, nil)
 	c.JSON(200, gin.H{"message": "Workflow deleted"})
 }
 
+// updateWorkflowTrigger is the single-trigger API kept for older clients. It
+// manages the schedule trigger attached to the workflow's start node; use the
+// /triggers endpoints to manage several triggers.
 func (h *Handler) updateWorkflowTrigger(c *gin.Context) {
 	id := c.Param("id")
 	var req struct {
@@ -164,20 +250,39 @@ func (h *Handler) updateWorkflowTrigger(c *gin.Context) {
 		return
 	}
 
-	if req.TriggerType != "manual" && req.TriggerType != "cron" && req.TriggerType != "webhook" {
-		c.JSON(400, gin.H{"error": "trigger_type must be 'manual', 'cron', or 'webhook'"})
+	req.TriggerType = normaliseTriggerType(req.TriggerType)
+	if req.TriggerType != TriggerTypeManual && req.TriggerType != TriggerTypeSchedule && req.TriggerType != TriggerTypeWebhook {
+		c.JSON(400, gin.H{"error": "trigger_type must be 'manual', 'schedule', or 'webhook'"})
 		return
 	}
 
-	if req.TriggerType == "cron" {
+	if req.TriggerType == TriggerTypeSchedule {
 		if req.CronSchedule == nil || *req.CronSchedule == "" {
-			c.JSON(400, gin.H{"error": "cron_schedule is required when trigger_type is 'cron'"})
+			c.JSON(400, gin.H{"error": "cron_schedule is required when trigger_type is 'schedule'"})
 			return
 		}
-		if !isValidCronSchedule(*req.CronSchedule) {
-			c.JSON(400, gin.H{"error": "Invalid cron schedule format"})
+		if _, err := parseCronSchedule(*req.CronSchedule); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid cron schedule: " + err.Error()})
 			return
 		}
+	} else {
+		req.CronSchedule = nil
+	}
+
+	var nodesJSON json.RawMessage
+	if err := h.db.QueryRow("SELECT nodes FROM workflows WHERE id = ?", id).Scan(&nodesJSON); err == sql.ErrNoRows {
+		c.JSON(404, gin.H{"error": "Workflow not found"})
+		return
+	}
+	if startNodeID := findStartNodeID(nodesJSON); startNodeID != "" {
+		if req.TriggerType == TriggerTypeSchedule {
+			h.upsertNodeTrigger(id, startNodeID, TriggerTypeSchedule, "Schedule", req.CronSchedule, nil, false)
+		} else {
+			h.db.Exec("DELETE FROM workflow_triggers WHERE workflow_id = ? AND node_id = ? AND type = ?", id, startNodeID, TriggerTypeSchedule)
+		}
+	} else if err := h.setWorkflowSchedule(id, req.CronSchedule); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
 	}
 
 	_, err := h.db.Exec(
@@ -188,17 +293,66 @@ func (h *Handler) updateWorkflowTrigger(c *gin.Context) {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
+	h.refreshWorkflowSchedule(id)
 	c.JSON(200, gin.H{"message": "Trigger updated"})
 }
 
-func isValidCronSchedule(schedule string) bool {
-	schedule = strings.TrimSpace(schedule)
-	if schedule == "" {
-		return false
+// setWorkflowSchedule manages the schedule of a workflow without a start
+// node: its oldest API-managed (node_id NULL) schedule trigger is updated,
+// created, or deleted when cronSchedule is nil.
+func (h *Handler) setWorkflowSchedule(workflowID string, cronSchedule *string) error {
+	var triggerID string
+	err := h.db.QueryRow(
+		"SELECT id FROM workflow_triggers WHERE workflow_id = ? AND node_id IS NULL AND type = ? ORDER BY created_at LIMIT 1",
+		workflowID, TriggerTypeSchedule,
+	).Scan(&triggerID)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if cronSchedule == nil {
+		if triggerID == "" {
+			return nil
+		}
+		_, err = h.db.Exec("DELETE FROM workflow_triggers WHERE id = ?", triggerID)
+		return err
+	}
+	if triggerID != "" {
+		_, err = h.db.Exec("UPDATE workflow_triggers SET cron_schedule = ? WHERE id = ?", cronSchedule, triggerID)
+		return err
+	}
+	_, err = h.db.Exec(
+		"INSERT INTO workflow_triggers (id, workflow_id, type, name, cron_schedule, input) VALUES (?, ?, ?, 'Schedule', ?, '{}')",
+		uuid.New().String(), workflowID, TriggerTypeSchedule, cronSchedule,
+	)
+	return err
+}
+
+// startNodeScheduleInput returns the start node's schedule_input as a JSON
+// object, or nil when it is unset (validateTriggerFilters rejects anything
+// but an object on save).
+func startNodeScheduleInput(data map[string]interface{}) json.RawMessage {
+	raw, _ := data["schedule_input"].(string)
+	if strings.TrimSpace(raw) == "" {
+		return nil
 	}
-	if strings.HasPrefix(schedule, "@every ") || schedule == "@hourly" ||
-		schedule == "@daily" || schedule == "@weekly" || schedule == "@monthly" {
-		return true
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &obj); err != nil {
+		return nil
+	}
+	return json.RawMessage(raw)
+}
+
+// findStartNodeID returns the id of the start node, or "" if there is none.
+func findStartNodeID(nodesJSON json.RawMessage) string {
+	var nodes []struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	}
+	json.Unmarshal(nodesJSON, &nodes)
+	for _, n := range nodes {
+		if n.Type == "start" {
+			return n.ID
+		}
 	}
-	return len(strings.Fields(schedule)) == 5
+	return ""
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"database/sql"
 	"log"
+	"os"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -25,6 +26,18 @@ func main() {
 
 	h := handlers.New(db)
 
+	// "rotate-keys" re-encrypts every integration config with the first key
+	// in INTEGRATION_KEYS and exits; run it after adding a new key.
+	if len(os.Args) > 1 && os.Args[1] == "rotate-keys" {
+		n, err := h.RotateIntegrationKeys(true)
+		if err != nil {
+			log.Fatalf("Key rotation failed after %d integration(s): %v", n, err)
+		}
+		log.Printf("Re-encrypted %d integration(s)", n)
+		return
+	}
+	h.EncryptPlaintextIntegrations()
+
 	r := gin.Default()
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:3000"},
@@ -35,7 +48,10 @@ func main() {
 
 	h.RegisterRoutes(r)
 
+	h.ResumeBackfills()
 	go h.StartCronScheduler()
+	go h.StartWebhookEventRetention()
+	go h.StartIntegrationHealthChecks()
 
 	log.Println("Server running on http://localhost:8081")
 	r.Run(":8081")
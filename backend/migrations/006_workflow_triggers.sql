@@ -0,0 +1,50 @@
+-- Migration: Multiple triggers per workflow
+
+CREATE TABLE IF NOT EXISTS workflow_triggers (
+    id VARCHAR(36) PRIMARY KEY,
+    workflow_id VARCHAR(36) NOT NULL,
+    type VARCHAR(20) NOT NULL COMMENT 'manual, schedule or webhook',
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    cron_schedule VARCHAR(100) NULL DEFAULT NULL COMMENT 'cron expression for schedule triggers',
+    node_id VARCHAR(36) NULL DEFAULT NULL COMMENT 'canvas node this trigger is derived from; NULL = managed via API',
+    input JSON NULL COMMENT 'static input payload merged into every run',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    last_fired_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_workflow_node (workflow_id, node_id),
+    INDEX idx_type_enabled (type, enabled),
+    FOREIGN KEY (workflow_id) REFERENCES workflows(id) ON DELETE CASCADE
+);
+
+-- Record which trigger started each run
+ALTER TABLE workflow_runs
+    ADD COLUMN trigger_id VARCHAR(36) NULL DEFAULT NULL,
+    ADD INDEX idx_trigger_id (trigger_id);
+
+-- Normalise legacy trigger type names: cron → schedule, trigger → webhook
+UPDATE workflows SET trigger_type = 'schedule' WHERE trigger_type = 'cron';
+UPDATE workflows SET trigger_type = 'webhook' WHERE trigger_type = 'trigger';
+ALTER TABLE workflows MODIFY COLUMN trigger_type VARCHAR(20) NOT NULL DEFAULT 'manual' COMMENT 'summary of workflow_triggers: manual, schedule or webhook';
+
+-- Carry existing schedules over, attached to the start node when there is one
+-- (one schedule per workflow, so only the first of several start nodes)
+INSERT INTO workflow_triggers (id, workflow_id, type, name, cron_schedule, node_id, last_fired_at)
+SELECT UUID(), w.id, 'schedule', 'Schedule', w.cron_schedule, MIN(n.node_id), w.last_cron_run
+FROM workflows w
+LEFT JOIN JSON_TABLE(w.nodes, '$[*]' COLUMNS (
+    node_id VARCHAR(36) PATH '$.id',
+    node_type VARCHAR(100) PATH '$.type'
+)) AS n ON n.node_type = 'start'
+WHERE w.trigger_type = 'schedule' AND w.cron_schedule IS NOT NULL
+GROUP BY w.id;
+
+-- Jira webhook trigger nodes become webhook triggers
+INSERT INTO workflow_triggers (id, workflow_id, type, name, node_id)
+SELECT UUID(), w.id, 'webhook', 'Jira webhook', n.node_id
+FROM workflows w,
+JSON_TABLE(w.nodes, '$[*]' COLUMNS (
+    node_id VARCHAR(36) PATH '$.id',
+    node_type VARCHAR(100) PATH '$.type'
+)) AS n
+WHERE n.node_type = 'jira_webhook';
@@ -0,0 +1,37 @@
+-- Migration: Blackout calendars, schedule pausing and skipped-tick log
+
+CREATE TABLE IF NOT EXISTS schedule_calendars (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE COMMENT 'e.g. Holiday freeze 2026',
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC' COMMENT 'IANA zone the windows are expressed in',
+    windows JSON NOT NULL COMMENT 'array of blackout windows (dates, weekly, range)',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+ALTER TABLE workflow_triggers
+    ADD COLUMN calendar_id VARCHAR(36) NULL DEFAULT NULL COMMENT 'blackout calendar for schedule triggers',
+    ADD COLUMN paused BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'paused schedules keep ticking but skip their runs';
+
+-- Process-wide scheduler settings (global pause)
+CREATE TABLE IF NOT EXISTS scheduler_settings (
+    name VARCHAR(50) PRIMARY KEY,
+    value VARCHAR(255) NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+INSERT IGNORE INTO scheduler_settings (name, value) VALUES ('paused', 'false');
+
+-- Every scheduled tick that did not start a run, and why
+CREATE TABLE IF NOT EXISTS schedule_skips (
+    id VARCHAR(36) PRIMARY KEY,
+    trigger_id VARCHAR(36) NOT NULL,
+    workflow_id VARCHAR(36) NOT NULL,
+    fire_time TIMESTAMP NOT NULL,
+    reason VARCHAR(30) NOT NULL COMMENT 'scheduler_paused, trigger_paused or blackout',
+    detail VARCHAR(255) NOT NULL DEFAULT '',
+    calendar_id VARCHAR(36) NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_workflow_fire (workflow_id, fire_time),
+    INDEX idx_trigger_fire (trigger_id, fire_time),
+    FOREIGN KEY (workflow_id) REFERENCES workflows(id) ON DELETE CASCADE
+);
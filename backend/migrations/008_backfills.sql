@@ -0,0 +1,22 @@
+-- Migration: Backfill runs for scheduled workflows
+
+CREATE TABLE IF NOT EXISTS backfills (
+    id VARCHAR(36) PRIMARY KEY,
+    workflow_id VARCHAR(36) NOT NULL,
+    trigger_id VARCHAR(36) NULL DEFAULT NULL,
+    cron_schedule VARCHAR(100) NOT NULL,
+    start_time TIMESTAMP NOT NULL,
+    end_time TIMESTAMP NOT NULL,
+    max_concurrency INT NOT NULL DEFAULT 1,
+    total_runs INT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'running' COMMENT 'running, completed or cancelled',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP NULL DEFAULT NULL,
+    INDEX idx_workflow (workflow_id),
+    FOREIGN KEY (workflow_id) REFERENCES workflows(id) ON DELETE CASCADE
+);
+
+ALTER TABLE workflow_runs
+    ADD COLUMN backfill_id VARCHAR(36) NULL DEFAULT NULL,
+    ADD INDEX idx_backfill_id (backfill_id),
+    MODIFY COLUMN status ENUM('pending', 'running', 'success', 'failed', 'cancelled') DEFAULT 'pending';
@@ -0,0 +1,13 @@
+-- Migration: Scheduled runs record their intended fire time
+
+ALTER TABLE workflow_runs
+    ADD COLUMN scheduled_for TIMESTAMP NULL DEFAULT NULL COMMENT 'fire time of the schedule tick that started this run',
+    ADD INDEX idx_trigger_scheduled (trigger_id, scheduled_for);
+
+-- Start node: static input merged into every scheduled run
+UPDATE node_schemas SET fields = JSON_ARRAY_APPEND(fields, '$',
+  JSON_OBJECT('key','schedule_input','label','Schedule Input (JSON)','type','code','required',FALSE,'default','',
+    'placeholder','{"report": "daily"}',
+    'hint','Static input for scheduled runs. Fire time and schedule details are added under {{schedule.*}}.','group','',
+    'show_if',JSON_OBJECT('field','trigger_type','value','schedule')))
+WHERE type = 'start';
@@ -0,0 +1,14 @@
+-- Migration: Generic per-workflow inbound webhook trigger
+
+ALTER TABLE workflow_triggers
+    ADD COLUMN webhook_token VARCHAR(64) NULL DEFAULT NULL COMMENT 'secret path segment for /webhooks/w/:token',
+    ADD UNIQUE KEY uk_webhook_token (webhook_token);
+
+ALTER TABLE webhook_events MODIFY COLUMN source VARCHAR(50) NOT NULL COMMENT 'jira, generic, etc.';
+
+INSERT INTO node_schemas (type, label, icon, color, description, auth_type, is_trigger, fields) VALUES
+('webhook', 'Webhook Trigger', '🪝', '#2b6cb0', 'Triggers the workflow when its unique webhook URL is called.', NULL, TRUE, JSON_ARRAY(
+  JSON_OBJECT('key','allowed_methods','label','Allowed Methods','type','text','required',FALSE,'default','POST',
+    'placeholder','e.g. POST or GET,POST',
+    'hint','Comma-separated HTTP methods accepted by the webhook URL.','group','')
+));
@@ -0,0 +1,6 @@
+-- Migration: Record signature verification outcome on inbound webhooks
+
+ALTER TABLE webhook_events
+    ADD COLUMN verification VARCHAR(30) NOT NULL DEFAULT 'not_configured' COMMENT 'verified, unsigned, invalid or not_configured',
+    ADD COLUMN quarantined BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'stored but not processed because verification failed',
+    ADD INDEX idx_quarantined (quarantined);
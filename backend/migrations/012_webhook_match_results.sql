@@ -0,0 +1,5 @@
+-- Migration: Record per-trigger match results on inbound webhooks
+
+ALTER TABLE webhook_events
+    ADD COLUMN match_results JSON NULL COMMENT 'per trigger node: workflow_id, node_id, matched, reason, run_id',
+    ADD COLUMN skip_reason VARCHAR(500) NULL COMMENT 'why no workflow ran for this event';
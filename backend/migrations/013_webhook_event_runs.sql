@@ -0,0 +1,18 @@
+-- Migration: Link a webhook event to every run it started
+
+CREATE TABLE IF NOT EXISTS webhook_event_runs (
+    event_id VARCHAR(36) NOT NULL,
+    workflow_run_id VARCHAR(36) NOT NULL,
+    workflow_id VARCHAR(36) NOT NULL,
+    trigger_id VARCHAR(36) NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (event_id, workflow_run_id),
+    INDEX idx_workflow_run (workflow_run_id),
+    FOREIGN KEY (event_id) REFERENCES webhook_events(id) ON DELETE CASCADE,
+    FOREIGN KEY (workflow_run_id) REFERENCES workflow_runs(id) ON DELETE CASCADE
+);
+
+INSERT IGNORE INTO webhook_event_runs (event_id, workflow_run_id, workflow_id, trigger_id, created_at)
+SELECT e.id, e.workflow_run_id, r.workflow_id, r.trigger_id, e.created_at
+FROM webhook_events e
+JOIN workflow_runs r ON r.id = e.workflow_run_id;
@@ -0,0 +1,8 @@
+-- Migration: Mark runs started by replaying a stored webhook event
+
+ALTER TABLE workflow_runs
+    ADD COLUMN replay_of_event_id VARCHAR(36) NULL DEFAULT NULL COMMENT 'webhook event replayed to start this run',
+    ADD INDEX idx_replay_of_event (replay_of_event_id);
+
+ALTER TABLE webhook_event_runs
+    ADD COLUMN replay BOOLEAN NOT NULL DEFAULT FALSE;
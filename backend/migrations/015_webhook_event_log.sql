@@ -0,0 +1,5 @@
+-- Migration: Index webhook events for cursor pagination and retention
+
+ALTER TABLE webhook_events
+    ADD INDEX idx_created_id (created_at, id),
+    ADD INDEX idx_event_type (event_type);
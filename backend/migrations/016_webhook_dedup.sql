@@ -0,0 +1,5 @@
+-- Migration: Deduplicate retried webhook deliveries
+
+ALTER TABLE webhook_events
+    ADD COLUMN dedup_key CHAR(64) NULL DEFAULT NULL COMMENT 'sha256 of the delivery identity; released once outside the dedup window',
+    ADD UNIQUE KEY uk_source_dedup_key (source, dedup_key);
@@ -0,0 +1,20 @@
+-- Migration: Track webhooks registered on Jira
+
+CREATE TABLE IF NOT EXISTS jira_webhooks (
+    id VARCHAR(36) PRIMARY KEY,
+    jira_webhook_id VARCHAR(50) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    url VARCHAR(1000) NOT NULL,
+    events JSON NOT NULL,
+    jql_filter TEXT NULL,
+    workflow_id VARCHAR(36) NULL DEFAULT NULL,
+    signed BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(20) NOT NULL DEFAULT 'active' COMMENT 'active, missing (deleted on Jira) or drifted (changed on Jira)',
+    drift JSON NULL COMMENT 'fields that differ on Jira, as of the last reconcile',
+    last_reconciled_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_jira_webhook_id (jira_webhook_id),
+    INDEX idx_workflow (workflow_id),
+    FOREIGN KEY (workflow_id) REFERENCES workflows(id) ON DELETE SET NULL
+);
@@ -0,0 +1,15 @@
+-- Migration: GitHub webhook trigger node
+
+INSERT INTO node_schemas (type, label, icon, color, description, auth_type, is_trigger, fields) VALUES
+('github_webhook', 'GitHub Webhook Trigger', '🐙', '#24292e', 'Triggers the workflow on GitHub events delivered to /webhooks/github.', NULL, TRUE, JSON_ARRAY(
+  JSON_OBJECT('key','event','label','Events','type','text','required',FALSE,'default','',
+    'placeholder','e.g. push, pull_request, release',
+    'hint','Comma-separated X-GitHub-Event names. Empty matches all events.','group',''),
+  JSON_OBJECT('key','action','label','Actions','type','text','required',FALSE,'default','',
+    'placeholder','e.g. opened, closed, published','group',''),
+  JSON_OBJECT('key','repository','label','Repositories','type','text','required',FALSE,'default','',
+    'placeholder','e.g. acme/api, acme/*','group',''),
+  JSON_OBJECT('key','branch','label','Branches','type','text','required',FALSE,'default','',
+    'placeholder','e.g. main, release/*',
+    'hint','Pushed branch, pull request base branch or check head branch. Glob patterns allowed.','group','')
+));
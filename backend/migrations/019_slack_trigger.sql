@@ -0,0 +1,26 @@
+-- Migration: Slack Events API and slash-command trigger node
+
+INSERT INTO node_schemas (type, label, icon, color, description, auth_type, is_trigger, fields) VALUES
+('slack_trigger', 'Slack Trigger', '💬', '#4A154B', 'Triggers the workflow from Slack messages, mentions, reactions or slash commands.', 'slack', TRUE, JSON_ARRAY(
+  JSON_OBJECT('key','events','label','Event Types','type','text','required',FALSE,'default','',
+    'placeholder','e.g. app_mention, slash_command',
+    'hint','Comma-separated: message, app_mention, reaction_added, slash_command. Empty matches all.','group',''),
+  JSON_OBJECT('key','channel','label','Channels','type','text','required',FALSE,'default','',
+    'placeholder','e.g. C01ABCD1234, #deploys','group',''),
+  JSON_OBJECT('key','command','label','Slash Command','type','text','required',FALSE,'default','',
+    'placeholder','e.g. /deploy',
+    'hint','Only slash commands with this name trigger the workflow.','group','')
+));
+
+-- Slack message: reply in the thread of the triggering Slack event
+UPDATE node_schemas SET fields = JSON_ARRAY_APPEND(fields, '$',
+  JSON_OBJECT('key','reply_in_thread','label','Reply to Slack Trigger','type','select','required',FALSE,'default','',
+    'options',JSON_ARRAY(
+      JSON_OBJECT('label','No','value',''),
+      JSON_OBJECT('label','Yes, in the triggering thread','value','true')
+    ),
+    'hint','Uses the channel and thread of the Slack event that started the run when Channel or Thread Timestamp is empty.','group','Advanced'))
+WHERE type = 'slack_message';
+
+UPDATE node_schemas SET fields = JSON_SET(fields, '$[0].required', FALSE)
+WHERE type = 'slack_message';
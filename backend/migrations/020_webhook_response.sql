@@ -0,0 +1,17 @@
+-- Migration: Respond to Webhook node for synchronous webhook responses
+
+INSERT INTO node_schemas (type, label, icon, color, description, auth_type, is_trigger, fields) VALUES
+('webhook_response', 'Respond to Webhook', '↩️', '#2b6cb0', 'Sends the HTTP response to the caller of the webhook that started the run.', NULL, FALSE, JSON_ARRAY(
+  JSON_OBJECT('key','status_code','label','Status Code','type','number','required',FALSE,'default',200,'group',''),
+  JSON_OBJECT('key','headers','label','Headers (JSON)','type','code','required',FALSE,'default','',
+    'placeholder','{"Content-Type": "application/json"}','group',''),
+  JSON_OBJECT('key','body','label','Body','type','code','required',FALSE,'default','',
+    'placeholder','{"ok": true, "ticket": "{{key}}"}',
+    'hint','JSON is sent as JSON, anything else as text. Leave empty to send the node input. Use {{key}} for template variables.','group','')
+));
+
+-- Webhook trigger: how long the caller is held waiting for a response
+UPDATE node_schemas SET fields = JSON_ARRAY_APPEND(fields, '$',
+  JSON_OBJECT('key','response_timeout','label','Response Timeout (seconds)','type','number','required',FALSE,'default',30,
+    'hint','Only used when the workflow has a Respond to Webhook node. Maximum 300.','group','Advanced'))
+WHERE type = 'webhook';
@@ -0,0 +1,7 @@
+-- Migration: payload filter expression on every event trigger node
+
+UPDATE node_schemas SET fields = JSON_ARRAY_APPEND(fields, '$',
+  JSON_OBJECT('key','filter_expression','label','Filter Expression','type','code','required',FALSE,'default','',
+    'placeholder','issue.fields.priority.name in ["High","Highest"] && changelog.items[0].field == "status"',
+    'hint','Only trigger when this expression is true for the payload. Operators: == != < > <= >= in && || ! and parentheses; paths like a.b[0].c.','group','Advanced'))
+WHERE type IN ('jira_webhook', 'webhook', 'github_webhook', 'slack_trigger');
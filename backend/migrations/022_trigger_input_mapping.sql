@@ -0,0 +1,10 @@
+-- Migration: map trigger payloads into a declared run input
+
+ALTER TABLE workflow_runs
+    ADD COLUMN trigger_payload JSON NULL DEFAULT NULL COMMENT 'raw event payload when the trigger node mapped it into the input' AFTER input;
+
+UPDATE node_schemas SET fields = JSON_ARRAY_APPEND(fields, '$',
+  JSON_OBJECT('key','input_mapping','label','Input Mapping (JSON)','type','code','required',FALSE,'default','',
+    'placeholder','{"key": "issue.key", "priority": "issue.fields.priority.name", "title": "[{{issue.key}}] {{issue.fields.summary}}"}',
+    'hint','Projects the payload into the run input. Values are payload paths ("$" is the whole payload) or {{...}} templates. The raw payload is kept on the run as trigger_payload.','group','Advanced'))
+WHERE type IN ('jira_webhook', 'webhook', 'github_webhook', 'slack_trigger');
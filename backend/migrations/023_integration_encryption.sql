@@ -0,0 +1,12 @@
+-- Migration: Envelope encryption of integration configs
+--
+-- Encrypted rows keep config NULL and store the AES-GCM ciphertext, the data
+-- key wrapped by the master key, and that master key's ID. Existing plaintext
+-- rows are encrypted by the server at startup once INTEGRATION_KEYS is set.
+
+ALTER TABLE integrations
+    MODIFY COLUMN config JSON NULL COMMENT 'plaintext config; NULL when encrypted',
+    ADD COLUMN config_key_id VARCHAR(64) NULL DEFAULT NULL COMMENT 'master key that wrapped config_dek' AFTER config,
+    ADD COLUMN config_dek VARBINARY(255) NULL DEFAULT NULL COMMENT 'data key, encrypted with the master key' AFTER config_key_id,
+    ADD COLUMN config_ciphertext MEDIUMBLOB NULL DEFAULT NULL COMMENT 'config JSON, encrypted with the data key' AFTER config_dek,
+    ADD INDEX idx_config_key_id (config_key_id);
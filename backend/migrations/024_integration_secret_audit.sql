@@ -0,0 +1,14 @@
+-- Migration: Audit log of integration secret reveals
+
+CREATE TABLE IF NOT EXISTS integration_secret_audit (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    integration_id VARCHAR(36) NULL COMMENT 'NULL when the request was refused before lookup',
+    integration_type VARCHAR(50) NOT NULL,
+    fields JSON NOT NULL COMMENT 'secret fields requested',
+    actor VARCHAR(255) NOT NULL DEFAULT '',
+    reason VARCHAR(1000) NOT NULL DEFAULT '',
+    client_ip VARCHAR(64) NOT NULL DEFAULT '',
+    outcome VARCHAR(20) NOT NULL COMMENT 'revealed, denied or disabled',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_secret_audit_type (integration_type, created_at)
+);
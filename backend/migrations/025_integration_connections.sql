@@ -0,0 +1,25 @@
+-- Migration: Multiple named connections per integration type
+--
+-- Each existing integration becomes the default connection of its type.
+-- default_type is only set on the default row, so its unique key allows one
+-- default per type.
+
+ALTER TABLE integrations
+    DROP INDEX uk_type,
+    ADD COLUMN is_default BOOLEAN NOT NULL DEFAULT FALSE AFTER name,
+    ADD COLUMN default_type VARCHAR(50) GENERATED ALWAYS AS (IF(is_default, type, NULL)) STORED,
+    ADD UNIQUE KEY uk_type_name (type, name),
+    ADD UNIQUE KEY uk_default_type (default_type);
+
+UPDATE integrations SET is_default = TRUE;
+
+-- Nodes that call an integration can pick a connection, shown first
+UPDATE node_schemas SET fields = JSON_ARRAY_INSERT(fields, '$[0]',
+  JSON_OBJECT('key','connection_id','label','Connection','type','connection','connection_type','jira','required',FALSE,'default','',
+    'hint','Leave empty to use the default Jira connection.','group',''))
+WHERE type = 'jira_create_issue';
+
+UPDATE node_schemas SET fields = JSON_ARRAY_INSERT(fields, '$[0]',
+  JSON_OBJECT('key','connection_id','label','Connection','type','connection','connection_type','slack','required',FALSE,'default','',
+    'hint','Leave empty to use the default Slack connection.','group',''))
+WHERE type = 'slack_message';
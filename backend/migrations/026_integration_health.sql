@@ -0,0 +1,7 @@
+-- Migration: Connection health from integration tests
+
+ALTER TABLE integrations
+    ADD COLUMN health_status VARCHAR(20) NOT NULL DEFAULT 'unknown' COMMENT 'ok, error or unknown (never tested)',
+    ADD COLUMN health_message TEXT NULL COMMENT 'error of the last failed test',
+    ADD COLUMN health_latency_ms INT NULL,
+    ADD COLUMN health_checked_at TIMESTAMP NULL DEFAULT NULL;
@@ -0,0 +1,29 @@
+-- Migration: OAuth 2.0 connections
+--
+-- Pending authorization-code requests, keyed by their state parameter. Each
+-- holds the PKCE code verifier until the provider redirects back to
+-- /oauth/callback; rows are single use and expire after 10 minutes.
+
+CREATE TABLE IF NOT EXISTS oauth_states (
+    state VARCHAR(128) PRIMARY KEY,
+    integration_id VARCHAR(36) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    redirect_uri VARCHAR(1000) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_oauth_states_expires (expires_at),
+    FOREIGN KEY (integration_id) REFERENCES integrations(id) ON DELETE CASCADE
+);
+
+-- HTTP Request nodes can authenticate with a generic OAuth 2.0 connection
+UPDATE node_schemas SET fields = JSON_ARRAY_APPEND(fields,
+  REPLACE(JSON_UNQUOTE(JSON_SEARCH(fields, 'one', 'auth_type', NULL, '$[*].key')), '.key', '.options'),
+  JSON_OBJECT('label','OAuth 2.0 Connection','value','oauth2'))
+WHERE type = 'http_request' AND JSON_SEARCH(fields, 'one', 'auth_type', NULL, '$[*].key') IS NOT NULL;
+
+UPDATE node_schemas SET fields = JSON_ARRAY_APPEND(fields, '$',
+  JSON_OBJECT('key','connection_id','label','OAuth 2.0 Connection','type','connection','connection_type','oauth2','required',FALSE,'default','',
+    'hint','Leave empty to use the default OAuth 2.0 connection. Its access token is sent as a bearer token and renewed automatically.',
+    'group','Authentication',
+    'show_if',JSON_OBJECT('field','auth_type','value','oauth2')))
+WHERE type = 'http_request';
@@ -0,0 +1,94 @@
+-- Migration: Jira node pack — update, transition, comment, assign, link, search
+
+INSERT INTO node_schemas (type, label, icon, color, description, auth_type, is_trigger, fields) VALUES
+
+('jira_update_issue', 'Jira Update Issue', '✏️', '#0052CC', 'Update fields of an existing Jira issue.', 'jira', FALSE, JSON_ARRAY(
+  JSON_OBJECT('key','connection_id','label','Connection','type','connection','connection_type','jira','required',FALSE,'default','',
+    'hint','Leave empty to use the default Jira connection.','group',''),
+  JSON_OBJECT('key','issue_key','label','Issue Key','type','text','required',TRUE,'default','',
+    'placeholder','e.g. WOP-123 or {{issue.key}}','group',''),
+  JSON_OBJECT('key','summary','label','Summary','type','text','required',FALSE,'default','',
+    'placeholder','Leave empty to keep the current summary','group',''),
+  JSON_OBJECT('key','description','label','Description','type','textarea','required',FALSE,'default','',
+    'placeholder','Plain text or an ADF document ({"type": "doc", ...})','group',''),
+  JSON_OBJECT('key','priority','label','Priority','type','select','required',FALSE,'default','',
+    'options',JSON_ARRAY(
+      JSON_OBJECT('label','(unchanged)','value',''),
+      JSON_OBJECT('label','Highest','value','Highest'),
+      JSON_OBJECT('label','High','value','High'),
+      JSON_OBJECT('label','Medium','value','Medium'),
+      JSON_OBJECT('label','Low','value','Low'),
+      JSON_OBJECT('label','Lowest','value','Lowest')
+    ),'group',''),
+  JSON_OBJECT('key','labels','label','Labels (comma-separated)','type','text','required',FALSE,'default','',
+    'placeholder','Replaces the current labels','group',''),
+  JSON_OBJECT('key','fields_json','label','Other Fields (JSON)','type','code','required',FALSE,'default','',
+    'placeholder','{"customfield_10014": "WOP-1", "components": [{"name": "Backend"}]}',
+    'hint','Any field by ID; overrides the fields above.','group','Advanced'),
+  JSON_OBJECT('key','notify_users','label','Notify Watchers','type','checkbox','required',FALSE,'default',TRUE,'group','Advanced')
+)),
+
+('jira_transition_issue', 'Jira Transition Issue', '🔁', '#0052CC', 'Move a Jira issue to another status.', 'jira', FALSE, JSON_ARRAY(
+  JSON_OBJECT('key','connection_id','label','Connection','type','connection','connection_type','jira','required',FALSE,'default','',
+    'hint','Leave empty to use the default Jira connection.','group',''),
+  JSON_OBJECT('key','issue_key','label','Issue Key','type','text','required',TRUE,'default','',
+    'placeholder','e.g. WOP-123 or {{issue.key}}','group',''),
+  JSON_OBJECT('key','status','label','Target Status','type','text','required',TRUE,'default','',
+    'placeholder','e.g. In Progress',
+    'hint','Status name (or transition name). The transition is looked up on the issue.','group',''),
+  JSON_OBJECT('key','comment','label','Comment','type','textarea','required',FALSE,'default','',
+    'placeholder','Optional comment added with the transition','group',''),
+  JSON_OBJECT('key','resolution','label','Resolution','type','text','required',FALSE,'default','',
+    'placeholder','e.g. Done — only if the transition screen has it','group','Advanced')
+)),
+
+('jira_add_comment', 'Jira Add Comment', '💭', '#0052CC', 'Add a comment to a Jira issue.', 'jira', FALSE, JSON_ARRAY(
+  JSON_OBJECT('key','connection_id','label','Connection','type','connection','connection_type','jira','required',FALSE,'default','',
+    'hint','Leave empty to use the default Jira connection.','group',''),
+  JSON_OBJECT('key','issue_key','label','Issue Key','type','text','required',TRUE,'default','',
+    'placeholder','e.g. WOP-123 or {{issue.key}}','group',''),
+  JSON_OBJECT('key','comment','label','Comment','type','textarea','required',TRUE,'default','',
+    'placeholder','Workflow {{workflow_name}} finished.',
+    'hint','Plain text (one paragraph per line) or an ADF document ({"type": "doc", ...}).','group',''),
+  JSON_OBJECT('key','visibility_role','label','Restrict to Role','type','text','required',FALSE,'default','',
+    'placeholder','e.g. Developers','group','Advanced')
+)),
+
+('jira_assign_issue', 'Jira Assign Issue', '👤', '#0052CC', 'Assign a Jira issue to a user.', 'jira', FALSE, JSON_ARRAY(
+  JSON_OBJECT('key','connection_id','label','Connection','type','connection','connection_type','jira','required',FALSE,'default','',
+    'hint','Leave empty to use the default Jira connection.','group',''),
+  JSON_OBJECT('key','issue_key','label','Issue Key','type','text','required',TRUE,'default','',
+    'placeholder','e.g. WOP-123 or {{issue.key}}','group',''),
+  JSON_OBJECT('key','assignee','label','Assignee','type','text','required',FALSE,'default','',
+    'placeholder','Account ID or email',
+    'hint','Empty unassigns the issue; "default" uses the project default assignee.','group','')
+)),
+
+('jira_link_issues', 'Jira Link Issues', '🔗', '#0052CC', 'Link two Jira issues.', 'jira', FALSE, JSON_ARRAY(
+  JSON_OBJECT('key','connection_id','label','Connection','type','connection','connection_type','jira','required',FALSE,'default','',
+    'hint','Leave empty to use the default Jira connection.','group',''),
+  JSON_OBJECT('key','outward_issue','label','Outward Issue','type','text','required',TRUE,'default','',
+    'placeholder','e.g. WOP-1','hint','The issue on the outward side of the link type, e.g. the one that blocks.','group',''),
+  JSON_OBJECT('key','link_type','label','Link Type','type','select','required',TRUE,'default','Relates',
+    'options',JSON_ARRAY(
+      JSON_OBJECT('label','relates to','value','Relates'),
+      JSON_OBJECT('label','blocks','value','Blocks'),
+      JSON_OBJECT('label','clones','value','Cloners'),
+      JSON_OBJECT('label','duplicates','value','Duplicate')
+    ),'group',''),
+  JSON_OBJECT('key','inward_issue','label','Inward Issue','type','text','required',TRUE,'default','',
+    'placeholder','e.g. WOP-2','group',''),
+  JSON_OBJECT('key','comment','label','Comment','type','textarea','required',FALSE,'default','',
+    'placeholder','Optional comment added with the link','group','Advanced')
+)),
+
+('jira_search', 'Jira Search', '🔎', '#0052CC', 'Find Jira issues with JQL. Outputs the matching issues as an array.', 'jira', FALSE, JSON_ARRAY(
+  JSON_OBJECT('key','connection_id','label','Connection','type','connection','connection_type','jira','required',FALSE,'default','',
+    'hint','Leave empty to use the default Jira connection.','group',''),
+  JSON_OBJECT('key','jql','label','JQL','type','code','required',TRUE,'default','',
+    'placeholder','project = WOP AND status = "In Progress" ORDER BY updated DESC','group',''),
+  JSON_OBJECT('key','fields','label','Fields (comma-separated)','type','text','required',FALSE,'default','',
+    'placeholder','summary,status,assignee','hint','Defaults to summary, status, assignee, priority, issuetype, created, updated.','group',''),
+  JSON_OBJECT('key','max_results','label','Max Results','type','number','required',FALSE,'default','100',
+    'hint','Pages are fetched until this many issues (at most 1000).','group','Advanced')
+));
@@ -0,0 +1,70 @@
+-- Migration: Rich Slack messaging — Block Kit, updates, ephemeral messages,
+-- file uploads and user lookup
+
+-- Slack message: what to do, after the connection field
+UPDATE node_schemas SET fields = JSON_ARRAY_INSERT(fields, '$[1]',
+  JSON_OBJECT('key','action','label','Action','type','select','required',FALSE,'default','',
+    'options',JSON_ARRAY(
+      JSON_OBJECT('label','Post message','value',''),
+      JSON_OBJECT('label','Post ephemeral message (one user)','value','ephemeral'),
+      JSON_OBJECT('label','Update message','value','update'),
+      JSON_OBJECT('label','Delete message','value','delete')
+    ),'group',''))
+WHERE type = 'slack_message';
+
+UPDATE node_schemas SET fields = JSON_SET(fields,
+  REPLACE(JSON_UNQUOTE(JSON_SEARCH(fields, 'one', 'message', NULL, '$[*].key')), '.key', '.required'), FALSE,
+  REPLACE(JSON_UNQUOTE(JSON_SEARCH(fields, 'one', 'message', NULL, '$[*].key')), '.key', '.hint'),
+  'Supports Slack mrkdwn formatting. Use {{key}} for template variables. With blocks, this is the notification fallback text.')
+WHERE type = 'slack_message' AND JSON_SEARCH(fields, 'one', 'message', NULL, '$[*].key') IS NOT NULL;
+
+UPDATE node_schemas SET fields = JSON_ARRAY_APPEND(fields, '$',
+  JSON_OBJECT('key','blocks','label','Blocks (Block Kit JSON)','type','code','required',FALSE,'default','',
+    'placeholder','[{"type": "section", "text": {"type": "mrkdwn", "text": "*{{summary}}*"}}]',
+    'hint','A blocks array or Block Kit Builder JSON; strings support {{key}}. Or {{path}} alone to use blocks from the input.','group',''),
+  '$',
+  JSON_OBJECT('key','message_ts','label','Message Timestamp','type','text','required',FALSE,'default','',
+    'placeholder','e.g. {{ts}} from the Slack Message node that posted it','group','',
+    'show_if',JSON_OBJECT('field','action','value','update,delete')),
+  '$',
+  JSON_OBJECT('key','user','label','User','type','text','required',FALSE,'default','',
+    'placeholder','User ID or email','hint','Only this user sees the message.','group','',
+    'show_if',JSON_OBJECT('field','action','value','ephemeral')))
+WHERE type = 'slack_message';
+
+UPDATE node_schemas SET fields = JSON_SET(fields, '$[2].hint',
+  'Channel ID, #name, or an email address to send a direct message. Bot must be a member.')
+WHERE type = 'slack_message' AND JSON_UNQUOTE(JSON_EXTRACT(fields, '$[2].key')) = 'channel';
+
+INSERT INTO node_schemas (type, label, icon, color, description, auth_type, is_trigger, fields) VALUES
+
+('slack_upload_file', 'Slack Upload File', '📎', '#4A154B', 'Share a file built from run data in a Slack channel.', 'slack', FALSE, JSON_ARRAY(
+  JSON_OBJECT('key','connection_id','label','Connection','type','connection','connection_type','slack','required',FALSE,'default','',
+    'hint','Leave empty to use the default Slack connection.','group',''),
+  JSON_OBJECT('key','channel','label','Channel','type','text','required',TRUE,'default','',
+    'placeholder','e.g. #reports, C01ABCD1234 or user@example.com','group',''),
+  JSON_OBJECT('key','filename','label','File Name','type','text','required',TRUE,'default','',
+    'placeholder','e.g. report-{{date}}.csv','group',''),
+  JSON_OBJECT('key','content','label','Content','type','textarea','required',FALSE,'default','',
+    'placeholder','{{report}}','hint','File content; supports {{key}} template variables.','group',''),
+  JSON_OBJECT('key','initial_comment','label','Message','type','textarea','required',FALSE,'default','',
+    'placeholder','Here is the daily report','group',''),
+  JSON_OBJECT('key','title','label','Title','type','text','required',FALSE,'default','',
+    'placeholder','Defaults to the file name','group','Advanced'),
+  JSON_OBJECT('key','content_path','label','Content From Input Path','type','text','required',FALSE,'default','',
+    'placeholder','e.g. body.items',
+    'hint','Takes the content from this input path instead; objects and arrays are written as JSON.','group','Advanced'),
+  JSON_OBJECT('key','base64','label','Content Is Base64','type','checkbox','required',FALSE,'default',FALSE,
+    'hint','Decode the content, for binary files.','group','Advanced'),
+  JSON_OBJECT('key','thread_ts','label','Thread Timestamp','type','text','required',FALSE,'default','',
+    'placeholder','e.g. {{ts}}','group','Advanced')
+)),
+
+('slack_lookup_user', 'Slack Lookup User', '🔍', '#4A154B', 'Find a Slack user by email, e.g. to DM or mention them.', 'slack', FALSE, JSON_ARRAY(
+  JSON_OBJECT('key','connection_id','label','Connection','type','connection','connection_type','slack','required',FALSE,'default','',
+    'hint','Leave empty to use the default Slack connection.','group',''),
+  JSON_OBJECT('key','email','label','Email','type','text','required',TRUE,'default','',
+    'placeholder','e.g. {{issue.fields.assignee.emailAddress}}','group',''),
+  JSON_OBJECT('key','fail_if_missing','label','Fail If Not Found','type','checkbox','required',FALSE,'default',FALSE,
+    'hint','Otherwise outputs found: false.','group','')
+));
@@ -0,0 +1,4 @@
+-- Migration: API-managed schedule triggers have node_id NULL, as documented
+-- on the column; earlier versions stored '' for workflows without a start node.
+
+UPDATE workflow_triggers SET node_id = NULL WHERE node_id = '';
@@ -0,0 +1,11 @@
+-- Migration: Jira webhooks belong to the jira connection they were
+-- registered with. Existing webhooks were registered with the default one.
+-- Webhook IDs are only unique within a Jira site.
+
+ALTER TABLE jira_webhooks
+    ADD COLUMN connection_id VARCHAR(36) NULL DEFAULT NULL AFTER jira_webhook_id,
+    DROP INDEX uk_jira_webhook_id,
+    ADD UNIQUE KEY uk_connection_jira_webhook (connection_id, jira_webhook_id);
+
+UPDATE jira_webhooks
+SET connection_id = (SELECT id FROM integrations WHERE default_type = 'jira');
@@ -3,9 +3,19 @@ import {
   getIntegrations,
   upsertIntegration,
   deleteIntegration,
+  type ConnectionTestResult,
   type Integration,
 } from "./api";
 
+// describeTest summarises a connection test for the save confirmation
+function describeTest(test?: ConnectionTestResult): string {
+  if (!test) return "";
+  if (!test.ok) return `\n\nConnection test failed: ${test.error}`;
+  const who =
+    test.identity?.display_name ?? test.identity?.user ?? test.identity?.login;
+  return `\n\nConnection test passed${who ? ` as ${who}` : ""} (${test.latency_ms} ms).`;
+}
+
 interface IntegrationSettingsProps {
   onBack: () => void;
 }
@@ -32,8 +42,9 @@ export default function IntegrationSettings({
       const data = await getIntegrations();
       setIntegrations(data);
 
-      // Populate forms from existing configs
+      // Populate forms from the default connection of each type
       for (const i of data) {
+        if (!i.is_default) continue;
         if (i.type === "jira") {
           setJiraDomain(i.config.domain || "");
           setJiraEmail(i.config.email || "");
@@ -58,13 +69,14 @@ export default function IntegrationSettings({
   const handleSaveJira = async () => {
     setSaving("jira");
     try {
-      await upsertIntegration("jira", "Jira Cloud", {
-        domain: jiraDomain,
-        email: jiraEmail,
-        api_token: jiraApiToken,
-      });
+      const res = await upsertIntegration(
+        "jira",
+        "Jira Cloud",
+        { domain: jiraDomain, email: jiraEmail, api_token: jiraApiToken },
+        true,
+      );
       await fetchIntegrations();
-      alert("Jira integration saved!");
+      alert("Jira integration saved!" + describeTest(res.test));
     } catch (err) {
       console.error(err);
       alert("Failed to save Jira integration");
@@ -76,12 +88,14 @@ export default function IntegrationSettings({
   const handleSaveSlack = async () => {
     setSaving("slack");
     try {
-      await upsertIntegration("slack", "Slack Bot", {
-        bot_token: slackBotToken,
-        signing_secret: slackSigningSecret,
-      });
+      const res = await upsertIntegration(
+        "slack",
+        "Slack Bot",
+        { bot_token: slackBotToken, signing_secret: slackSigningSecret },
+        true,
+      );
       await fetchIntegrations();
-      alert("Slack integration saved!");
+      alert("Slack integration saved!" + describeTest(res.test));
     } catch (err) {
       console.error(err);
       alert("Failed to save Slack integration");
@@ -111,6 +125,10 @@ export default function IntegrationSettings({
 
   const isJiraConfigured = integrations.some((i) => i.type === "jira");
   const isSlackConfigured = integrations.some((i) => i.type === "slack");
+  const isFailing = (type: string) =>
+    integrations.some(
+      (i) => i.type === type && i.is_default && i.health_status === "error",
+    );
 
   if (loading) {
     return (
@@ -144,9 +162,12 @@ export default function IntegrationSettings({
           <div className="integration-title">
             <span className="integration-icon">🎫</span>
             <h2>Jira Cloud</h2>
-            {isJiraConfigured && (
+            {isJiraConfigured && !isFailing("jira") && (
               <span className="badge badge-active">Connected</span>
             )}
+            {isFailing("jira") && (
+              <span className="badge badge-error">Connection failing</span>
+            )}
           </div>
           {isJiraConfigured && (
             <button
@@ -218,7 +239,8 @@ export default function IntegrationSettings({
             </code>
             <p className="field-hint">
               Use a tool like ngrok to expose your local server, or deploy to a
-              public server.
+              public server. For a connection other than the default, append its
+              id: /webhooks/jira/&lt;connection id&gt;.
             </p>
           </div>
         </div>
@@ -230,9 +252,12 @@ export default function IntegrationSettings({
           <div className="integration-title">
             <span className="integration-icon">💬</span>
             <h2>Slack Bot</h2>
-            {isSlackConfigured && (
+            {isSlackConfigured && !isFailing("slack") && (
               <span className="badge badge-active">Connected</span>
             )}
+            {isFailing("slack") && (
+              <span className="badge badge-error">Connection failing</span>
+            )}
           </div>
           {isSlackConfigured && (
             <button
@@ -266,7 +291,7 @@ export default function IntegrationSettings({
             </p>
           </div>
           <div className="form-group">
-            <label>Signing Secret (optional)</label>
+            <label>Signing Secret (required for Slack triggers)</label>
             <input
               type="password"
               value={slackSigningSecret}
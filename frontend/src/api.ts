@@ -140,7 +140,13 @@ export interface Integration {
   id: string;
   type: string;
   name: string;
+  is_default: boolean;
   config: Record<string, string>;
+  secret_fields?: string[];
+  health_status: "ok" | "error" | "unknown";
+  health_message: string;
+  health_latency_ms: number | null;
+  health_checked_at: string | null;
   created_at: string;
   updated_at: string;
 }
@@ -158,12 +164,24 @@ export async function getIntegration(type: string): Promise<Integration> {
   return res.json();
 }
 
+export interface ConnectionTestResult {
+  ok: boolean;
+  identity?: Record<string, unknown>;
+  scopes?: string[];
+  latency_ms: number;
+  error?: string;
+  checked_at: string;
+}
+
+// With test set, the saved credentials are tested and the result returned.
 export async function upsertIntegration(
   type: string,
   name: string,
   config: Record<string, string>,
-): Promise<{ id: string }> {
-  const res = await fetch(`${API_BASE}/integrations/${type}`, {
+  test = false,
+): Promise<{ id: string; test?: ConnectionTestResult }> {
+  const query = test ? "?test=true" : "";
+  const res = await fetch(`${API_BASE}/integrations/${type}${query}`, {
     method: "PUT",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify({ name, config }),
@@ -179,6 +197,48 @@ export async function deleteIntegration(type: string): Promise<void> {
   if (!res.ok) throw new Error("Failed to delete integration");
 }
 
+export async function testIntegration(
+  type: string,
+): Promise<ConnectionTestResult> {
+  const res = await fetch(`${API_BASE}/integrations/${type}/test`, {
+    method: "POST",
+  });
+  if (!res.ok) throw new Error("Failed to test integration");
+  return res.json();
+}
+
+// ---- Connections ----
+
+export async function getConnections(type?: string): Promise<Integration[]> {
+  const query = type ? `?type=${encodeURIComponent(type)}` : "";
+  const res = await fetch(`${API_BASE}/connections${query}`);
+  if (!res.ok) throw new Error("Failed to fetch connections");
+  const data = await res.json();
+  return data ?? [];
+}
+
+// Starts connecting an OAuth 2.0 connection. Authorization-code connections
+// return the consent URL to open; client-credentials ones connect at once.
+export async function authorizeConnection(
+  id: string,
+): Promise<{ authorize_url?: string; status?: string }> {
+  const res = await fetch(`${API_BASE}/connections/${id}/oauth/authorize`, {
+    method: "POST",
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(err.error || "Failed to authorize connection");
+  }
+  return res.json();
+}
+
+export async function disconnectConnection(id: string): Promise<void> {
+  const res = await fetch(`${API_BASE}/connections/${id}/oauth`, {
+    method: "DELETE",
+  });
+  if (!res.ok) throw new Error("Failed to disconnect connection");
+}
+
 // ---- Webhook Events ----
 
 export interface WebhookEvent {
@@ -195,7 +255,7 @@ export async function getWebhookEvents(): Promise<WebhookEvent[]> {
   const res = await fetch(`${API_BASE}/webhook-events`);
   if (!res.ok) throw new Error("Failed to fetch webhook events");
   const data = await res.json();
-  return data ?? [];
+  return data?.events ?? [];
 }
 
 // ---- Run workflow ----
@@ -325,7 +385,8 @@ export interface SchemaField {
     | "select"
     | "checkbox"
     | "password"
-    | "code";
+    | "code"
+    | "connection";
   required?: boolean;
   default?: string;
   placeholder?: string;
@@ -333,6 +394,7 @@ export interface SchemaField {
   group?: string; // section header, "" = default section
   options?: SchemaFieldOption[];
   show_if?: SchemaFieldShowIf;
+  connection_type?: string; // integration type listed by a "connection" field
 }
 
 export interface ExecuteConfig {
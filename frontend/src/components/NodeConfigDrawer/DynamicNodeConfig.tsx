@@ -1,6 +1,6 @@
-import { useState } from "react";
+import { useEffect, useState } from "react";
 import type { NodeConfigProps } from "../../constants/nodeTypes";
-import type { SchemaField } from "../../api";
+import { getConnections, type Integration, type SchemaField } from "../../api";
 
 interface DynamicNodeConfigProps extends NodeConfigProps {
   fields: SchemaField[];
@@ -19,6 +19,37 @@ const CUSTOM_FIELD_EXAMPLES: Record<string, string> = {
   start: 'e.g. key: "source" → tag this run with a custom label',
 };
 
+// Select for a "connection" field, listing the connections of its type
+const ConnectionSelect = ({
+  type,
+  value,
+  onChange,
+}: {
+  type: string;
+  value: string;
+  onChange: (value: string) => void;
+}) => {
+  const [connections, setConnections] = useState<Integration[]>([]);
+
+  useEffect(() => {
+    getConnections(type)
+      .then(setConnections)
+      .catch(() => setConnections([]));
+  }, [type]);
+
+  return (
+    <select value={value} onChange={(e) => onChange(e.target.value)}>
+      <option value="">Default connection</option>
+      {connections.map((conn) => (
+        <option key={conn.id} value={conn.id}>
+          {conn.name}
+          {conn.is_default ? " (default)" : ""}
+        </option>
+      ))}
+    </select>
+  );
+};
+
 export const DynamicNodeConfig = ({
   str,
   set,
@@ -200,6 +231,22 @@ export const DynamicNodeConfig = ({
           </div>
         );
 
+      case "connection":
+        return (
+          <div className="form-group" key={field.key}>
+            <label>
+              {field.label}
+              {field.required && " *"}
+            </label>
+            <ConnectionSelect
+              type={field.connection_type ?? ""}
+              value={str(field.key)}
+              onChange={(v) => set(field.key, v)}
+            />
+            {field.hint && <p className="field-hint">{field.hint}</p>}
+          </div>
+        );
+
       case "checkbox":
         return (
           <div className="form-group" key={field.key}>
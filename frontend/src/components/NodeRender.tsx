@@ -73,9 +73,44 @@ export function getNodeSummaryLines(
         });
       break;
 
+    case "jira_update_issue":
+    case "jira_add_comment":
+    case "jira_assign_issue":
+      if (str("issue_key"))
+        lines.push({ label: "Issue", value: str("issue_key") });
+      if (nodeType === "jira_assign_issue")
+        lines.push({ label: "To", value: str("assignee") || "Unassigned" });
+      break;
+
+    case "jira_transition_issue":
+      if (str("issue_key"))
+        lines.push({ label: "Issue", value: str("issue_key") });
+      if (str("status")) lines.push({ label: "To", value: str("status") });
+      break;
+
+    case "jira_link_issues":
+      if (str("outward_issue") || str("inward_issue"))
+        lines.push({
+          label: str("link_type") || "Relates",
+          value: `${str("outward_issue")} → ${str("inward_issue")}`,
+        });
+      break;
+
+    case "jira_search":
+      if (str("jql"))
+        lines.push({
+          label: "JQL",
+          value:
+            str("jql").length > 30 ? str("jql").slice(0, 30) + "…" : str("jql"),
+        });
+      break;
+
     case "slack_message":
+      if (str("action"))
+        lines.push({ label: "Action", value: str("action") });
       if (str("channel"))
         lines.push({ label: "Channel", value: str("channel") });
+      if (str("blocks")) lines.push({ label: "Blocks", value: "Block Kit" });
       if (str("message"))
         lines.push({
           label: "Message",
@@ -86,6 +121,17 @@ export function getNodeSummaryLines(
         });
       break;
 
+    case "slack_upload_file":
+      if (str("channel"))
+        lines.push({ label: "Channel", value: str("channel") });
+      if (str("filename"))
+        lines.push({ label: "File", value: str("filename") });
+      break;
+
+    case "slack_lookup_user":
+      if (str("email")) lines.push({ label: "Email", value: str("email") });
+      break;
+
     case "condition":
       if (str("condition_type") === "simple") {
         const expr = [str("field"), str("operator"), str("compare_value")]
@@ -166,7 +212,9 @@ export const NodeRender = (props: WorkflowNodeProps) => {
   const canDryRun = [
     "http_request",
     "jira_create_issue",
+    "jira_search",
     "slack_message",
+    "slack_lookup_user",
   ].includes(nodeType);
 
   const handleNodeClick = () => {
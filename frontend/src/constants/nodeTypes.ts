@@ -19,7 +19,15 @@ export const nodeTypesList: NodeTypeInfo[] = [
   { type: "jira_webhook", label: "Jira Webhook Trigger", icon: "🎫" },
   { type: "http_request", label: "HTTP Request", icon: "🌐" },
   { type: "jira_create_issue", label: "Jira Create Issue", icon: "📋" },
+  { type: "jira_update_issue", label: "Jira Update Issue", icon: "✏️" },
+  { type: "jira_transition_issue", label: "Jira Transition Issue", icon: "🔁" },
+  { type: "jira_add_comment", label: "Jira Add Comment", icon: "💭" },
+  { type: "jira_assign_issue", label: "Jira Assign Issue", icon: "👤" },
+  { type: "jira_link_issues", label: "Jira Link Issues", icon: "🔗" },
+  { type: "jira_search", label: "Jira Search", icon: "🔎" },
   { type: "slack_message", label: "Slack Message", icon: "💬" },
+  { type: "slack_upload_file", label: "Slack Upload File", icon: "📎" },
+  { type: "slack_lookup_user", label: "Slack Lookup User", icon: "🔍" },
   { type: "condition", label: "Condition", icon: "🔀" },
   { type: "transform", label: "Transform Data", icon: "🔄" },
   { type: "delay", label: "Delay", icon: "⏱️" },
@@ -33,7 +41,15 @@ export const nodeColors: Record<string, string> = {
   jira_webhook: "#0052CC",
   http_request: "#4299e1",
   jira_create_issue: "#0052CC",
+  jira_update_issue: "#0052CC",
+  jira_transition_issue: "#0052CC",
+  jira_add_comment: "#0052CC",
+  jira_assign_issue: "#0052CC",
+  jira_link_issues: "#0052CC",
+  jira_search: "#0052CC",
   slack_message: "#4A154B",
+  slack_upload_file: "#4A154B",
+  slack_lookup_user: "#4A154B",
   condition: "#ed8936",
   transform: "#48bb78",
   delay: "#9f7aea",